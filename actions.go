@@ -0,0 +1,233 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	fmt "fmt"
+	sort "sort"
+	strings "strings"
+	sync "sync"
+)
+
+// Default dead zone of gamepad axes, values below are considered as 0
+const defaultDeadZone = 0.2
+
+// Actions maps App actions ("jump", "move_x") to inputs, it allows to remap controls
+// without changing the App code. Bindings are given in text form:
+//
+//	key:<KeyCode>      key:Spacebar, key:LeftArrow
+//	mouse:<button>     mouse:Left, mouse:Right, mouse:Middle
+//	touch:<finger>     touch:First, touch:Second, touch:Third
+//	gamepad:<button>   gamepad:A, gamepad:DpadUp (see GamepadButton)
+//	axis:<axis>        axis:LeftX, axis:TriggerRight (see GamepadAxis)
+//
+// A leading "-" inverts the value of the binding, "move_x" can then be bound to
+// "key:RightArrow", "-key:LeftArrow" and "axis:LeftX". Actions are read from the
+// Input snapshot of the current tick like GetInput().
+type Actions struct {
+	mutex    sync.RWMutex
+	input    *inputState
+	bindings map[string][]binding
+	deadZone float32
+	userFS   *UserFS
+}
+
+// binding is the parsed form of an input binding
+type binding struct {
+	text   string
+	device string
+	code   int
+	scale  float32
+}
+
+func newActions(input *inputState) *Actions {
+	return &Actions{input: input, bindings: make(map[string][]binding), deadZone: defaultDeadZone}
+}
+
+func (core *runtimeCore) GetActions() *Actions {
+	return core.actions
+}
+
+// initActions binds the actions defined in Settings, userFS is used by Save()
+func (core *runtimeCore) initActions(settings Settings, userFS *UserFS) {
+	core.actions.userFS = userFS
+	for action, bindings := range settings.Actions {
+		if err := core.actions.Bind(action, bindings...); err != nil {
+			_logger.Error("Failed to bind action", "action", action, "error", err)
+		}
+	}
+}
+
+// Bind replaces the bindings of action, the action is removed if no binding is given
+func (a *Actions) Bind(action string, bindings ...string) error {
+	parsed := make([]binding, len(bindings))
+	for i, text := range bindings {
+		b, err := parseBinding(text)
+		if err != nil {
+			return err
+		}
+		parsed[i] = b
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(parsed) == 0 {
+		delete(a.bindings, action)
+	} else {
+		a.bindings[action] = parsed
+	}
+	return nil
+}
+
+// Bindings returns the bindings of action in text form
+func (a *Actions) Bindings(action string) []string {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	bindings := make([]string, len(a.bindings[action]))
+	for i, b := range a.bindings[action] {
+		bindings[i] = b.text
+	}
+	return bindings
+}
+
+// List returns the sorted names of bound actions
+func (a *Actions) List() []string {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	actions := make([]string, 0, len(a.bindings))
+	for action := range a.bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// SetDeadZone sets the amplitude under which gamepad axes are considered at rest
+func (a *Actions) SetDeadZone(deadZone float32) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.deadZone = deadZone
+}
+
+// IsPressed indicates if one of the inputs bound to action is active
+func (a *Actions) IsPressed(action string) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	input := a.input.getSnapshot()
+	for _, b := range a.bindings[action] {
+		if a.value(input, b) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the sum of the values of the inputs bound to action clamped to
+// [-1, 1], keys and buttons values are 0 or 1 and axes values are analog
+func (a *Actions) Value(action string) float32 {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	input := a.input.getSnapshot()
+	value := float32(0)
+	for _, b := range a.bindings[action] {
+		value += a.value(input, b)
+	}
+	if value > 1 {
+		return 1
+	} else if value < -1 {
+		return -1
+	}
+	return value
+}
+
+// Save writes the current bindings in the settings file of user data, they are
+// then loaded in Settings.Actions at next launch
+func (a *Actions) Save() error {
+	if a.userFS == nil {
+		return ErrNotSupported
+	}
+	return saveUserSettings(a.userFS, map[string]interface{}{"actions": a.getBindings()})
+}
+
+// getBindings returns all the bindings in text form
+func (a *Actions) getBindings() map[string][]string {
+	bindings := make(map[string][]string)
+	for _, action := range a.List() {
+		bindings[action] = a.Bindings(action)
+	}
+	return bindings
+}
+
+// value returns the value of a binding in the Input snapshot
+func (a *Actions) value(input *Input, b binding) float32 {
+	pressed := false
+	switch b.device {
+	case "key":
+		pressed = input.IsKeyDown(KeyCode(b.code))
+	case "mouse", "touch":
+		pressed = input.IsButtonDown(Button(b.code))
+	case "gamepad":
+		pressed = input.isGamepadButtonDown(GamepadButton(b.code))
+	case "axis":
+		if value := input.gamepadAxis(GamepadAxis(b.code)); value > a.deadZone || value < -a.deadZone {
+			return value * b.scale
+		}
+	}
+	if pressed {
+		return b.scale
+	}
+	return 0
+}
+
+// parseBinding parses the text form of a binding
+func parseBinding(text string) (binding, error) {
+	b := binding{text: text, scale: 1}
+	name := text
+	if strings.HasPrefix(name, "-") {
+		b.scale, name = -1, name[1:]
+	}
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 {
+		return b, fmt.Errorf("invalid binding %q, expected <device>:<input>", text)
+	}
+	b.device, name = parts[0], parts[1]
+
+	found := false
+	switch b.device {
+	case "key":
+		for code, keyName := range keyCodeNames {
+			if code != KeyCodeUnknown && strings.EqualFold(keyName, name) {
+				b.code, found = int(code), true
+			}
+		}
+	case "mouse":
+		for _, button := range []Button{ButtonLeft, ButtonRight, ButtonMiddle} {
+			if strings.EqualFold("Button"+name, button.String()) {
+				b.code, found = int(button), true
+			}
+		}
+	case "touch":
+		for _, button := range []Button{TouchFirst, TouchSecond, TouchThird} {
+			if strings.EqualFold("Touch"+name, button.String()) {
+				b.code, found = int(button), true
+			}
+		}
+	case "gamepad":
+		for code, buttonName := range gamepadButtonNames {
+			if GamepadButton(code) != GamepadButtonNone && strings.EqualFold(buttonName, name) {
+				b.code, found = code, true
+			}
+		}
+	case "axis":
+		for code, axisName := range gamepadAxisNames {
+			if GamepadAxis(code) != GamepadAxisNone && strings.EqualFold(axisName, name) {
+				b.code, found = code, true
+			}
+		}
+	default:
+		return b, fmt.Errorf("invalid binding %q, unknown device %q", text, b.device)
+	}
+	if !found {
+		return b, fmt.Errorf("invalid binding %q, unknown %s %q", text, b.device, name)
+	}
+	return b, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	testing "testing"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		text   string
+		device string
+		code   int
+		scale  float32
+		valid  bool
+	}{
+		{"key:Spacebar", "key", int(KeyCodeSpacebar), 1, true},
+		{"key:spacebar", "key", int(KeyCodeSpacebar), 1, true},
+		{"-key:LeftArrow", "key", int(KeyCodeLeftArrow), -1, true},
+		{"mouse:Left", "mouse", int(ButtonLeft), 1, true},
+		{"mouse:middle", "mouse", int(ButtonMiddle), 1, true},
+		{"touch:Second", "touch", int(TouchSecond), 1, true},
+		{"gamepad:A", "gamepad", int(GamepadButtonA), 1, true},
+		{"gamepad:DpadUp", "gamepad", int(GamepadButtonDpadUp), 1, true},
+		{"axis:LeftX", "axis", int(GamepadAxisLeftX), 1, true},
+		{"-axis:TriggerRight", "axis", int(GamepadAxisTriggerRight), -1, true},
+		{"", "", 0, 0, false},
+		{"Spacebar", "", 0, 0, false},
+		{"key:", "", 0, 0, false},
+		{"key:Unknown", "", 0, 0, false},
+		{"key:Nope", "", 0, 0, false},
+		{"mouse:Touch", "", 0, 0, false},
+		{"touch:Left", "", 0, 0, false},
+		{"gamepad:None", "", 0, 0, false},
+		{"axis:None", "", 0, 0, false},
+		{"joystick:A", "", 0, 0, false},
+		{"--key:A", "", 0, 0, false},
+	}
+	for _, test := range tests {
+		b, err := parseBinding(test.text)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("parseBinding(%q) should fail", test.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBinding(%q) failed: %s", test.text, err)
+			continue
+		}
+		if b.device != test.device || b.code != test.code || b.scale != test.scale || b.text != test.text {
+			t.Errorf("parseBinding(%q) = %+v, want {%s %d %v}", test.text, b, test.device, test.code, test.scale)
+		}
+	}
+}
+
+func TestActionsValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []Event
+		value   float32
+		pressed bool
+	}{
+		{"released", nil, 0, false},
+		{"key", []Event{KeyEvent{Key: KeyCodeRightArrow, Type: TypeDown}}, 1, true},
+		{"inverted key", []Event{KeyEvent{Key: KeyCodeLeftArrow, Type: TypeDown}}, -1, true},
+		{"opposite keys", []Event{
+			KeyEvent{Key: KeyCodeLeftArrow, Type: TypeDown},
+			KeyEvent{Key: KeyCodeRightArrow, Type: TypeDown},
+		}, 0, true},
+		{"key released", []Event{
+			KeyEvent{Key: KeyCodeRightArrow, Type: TypeDown},
+			KeyEvent{Key: KeyCodeRightArrow, Type: TypeUp},
+		}, 0, false},
+		{"axis", []Event{GamepadEvent{Axis: GamepadAxisLeftX, Value: 0.5, Type: TypeMove}}, 0.5, true},
+		{"axis in dead zone", []Event{GamepadEvent{Axis: GamepadAxisLeftX, Value: -0.1, Type: TypeMove}}, 0, false},
+		{"clamped", []Event{
+			KeyEvent{Key: KeyCodeRightArrow, Type: TypeDown},
+			GamepadEvent{Axis: GamepadAxisLeftX, Value: 0.5, Type: TypeMove},
+		}, 1, true},
+		{"gamepad button", []Event{GamepadEvent{Gamepad: 1, Button: GamepadButtonDpadRight, Type: TypeDown}}, 1, true},
+		{"mouse", []Event{MouseEvent{Button: ButtonRight, Type: TypeDown}}, 1, true},
+	}
+	for _, test := range tests {
+		input := newInputState()
+		actions := newActions(input)
+		if err := actions.Bind("move_x", "key:RightArrow", "-key:LeftArrow", "axis:LeftX", "gamepad:DpadRight", "mouse:Right"); err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range test.events {
+			input.update(event)
+		}
+		if value := actions.Value("move_x"); value != 0 {
+			t.Errorf("%s: Value() = %v before snapshot, want 0", test.name, value)
+		}
+		input.snapshot()
+		if value := actions.Value("move_x"); value != test.value {
+			t.Errorf("%s: Value() = %v, want %v", test.name, value, test.value)
+		}
+		if pressed := actions.IsPressed("move_x"); pressed != test.pressed {
+			t.Errorf("%s: IsPressed() = %v, want %v", test.name, pressed, test.pressed)
+		}
+		if value := actions.Value("jump"); value != 0 {
+			t.Errorf("%s: Value() of an unbound action = %v, want 0", test.name, value)
+		}
+	}
+}
+
+func TestActionsBind(t *testing.T) {
+	actions := newActions(newInputState())
+	if err := actions.Bind("jump", "key:Spacebar", "gamepad:A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := actions.Bind("jump", "key:Nope"); err == nil {
+		t.Error("Bind() of an invalid binding should fail")
+	}
+	if bindings := actions.Bindings("jump"); len(bindings) != 2 || bindings[0] != "key:Spacebar" || bindings[1] != "gamepad:A" {
+		t.Errorf("Bindings(jump) = %v after a failed Bind(), want [key:Spacebar gamepad:A]", bindings)
+	}
+	if err := actions.Bind("jump"); err != nil {
+		t.Fatal(err)
+	}
+	if list := actions.List(); len(list) != 0 {
+		t.Errorf("List() = %v after unbinding, want []", list)
+	}
+}
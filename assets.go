@@ -0,0 +1,298 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	errors "errors"
+	io "io"
+	fs "io/fs"
+	path "path"
+	sort "sort"
+	strings "strings"
+	sync "sync"
+	time "time"
+
+	pack "github.com/thommil/tge/pack"
+)
+
+// ErrNotSupported is returned when an operation is not available on the current target
+var ErrNotSupported = errors.New("operation not supported on this target")
+
+// AssetChangedEvent is published on "asset" channel when an asset is modified, created or
+// removed in development mode, it allows Apps and plugins to reload their resources
+type AssetChangedEvent struct {
+	// Path of the asset in the assets tree
+	Path string
+}
+
+// Channel of AssetChangedEvent = "asset"
+func (e AssetChangedEvent) Channel() string {
+	return "asset"
+}
+
+// -------------------------------------------------------------------- //
+// Assets access
+// -------------------------------------------------------------------- //
+
+func (core *runtimeCore) GetAsset(p string) ([]byte, error) {
+	name, err := core.resolveAsset(p)
+	if err != nil {
+		return nil, err
+	}
+	content, err := core.assetCache.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), content...), nil
+}
+
+func (core *runtimeCore) OpenAsset(p string) (io.ReadSeekCloser, error) {
+	name, err := core.resolveAsset(p)
+	if err != nil {
+		return nil, err
+	}
+	file, err := core.getAssets().Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if readSeekCloser, ok := file.(io.ReadSeekCloser); ok {
+		return readSeekCloser, nil
+	}
+	file.Close()
+	return nil, &fs.PathError{Op: "open", Path: name, Err: ErrNotSupported}
+}
+
+func (core *runtimeCore) GetAssetFS() fs.FS {
+	// Packs mounted later are visible through the returned value
+	return runtimeAssetFS{core}
+}
+
+// getAssets returns the current assets file system
+func (core *runtimeCore) getAssets() fs.FS {
+	core.assetsMutex.RLock()
+	defer core.assetsMutex.RUnlock()
+	return core.assets
+}
+
+func (core *runtimeCore) MountAssetPack(p string) error {
+	file, err := core.OpenAsset(p)
+	if err != nil {
+		return err
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	reader, ok := file.(io.ReaderAt)
+	if !ok {
+		reader = &seekReaderAt{readSeeker: file}
+	}
+	assetPack, err := pack.Open(reader, size)
+	if err != nil {
+		file.Close()
+		return &fs.PathError{Op: "mount", Path: p, Err: err}
+	}
+	core.assetsMutex.Lock()
+	core.assets = assetFS{overlayFS{top: assetPack, bottom: core.assets}}
+	core.assetPacks = append(core.assetPacks, file)
+	core.assetsMutex.Unlock()
+	core.assetCache.Purge()
+	core.clearVariants()
+	_logger.Info("Asset pack mounted", "pack", p, "entries", len(assetPack.Manifest().Entries))
+	return nil
+}
+
+// initAssets sets the assets file system and the cache, packs defined in Settings
+// are mounted at startup
+func (core *runtimeCore) initAssets(assets fs.FS, settings Settings) {
+	core.assetsMutex.Lock()
+	core.assets = assets
+	core.baseAssets = assets
+	core.assetsMutex.Unlock()
+	core.assetCache = newAssetCache(settings.AssetCacheSize, core.readAsset)
+	core.mountAssetPacks(settings)
+}
+
+// mountAssetPacks mounts the packs defined in Settings if they are not mounted, it
+// allows to restore them after a dispose on mobile
+func (core *runtimeCore) mountAssetPacks(settings Settings) {
+	core.assetsMutex.RLock()
+	mounted := len(core.assetPacks) > 0
+	core.assetsMutex.RUnlock()
+	if mounted {
+		return
+	}
+	for _, p := range settings.AssetPacks {
+		if err := core.MountAssetPack(p); err != nil {
+			_logger.Error("Failed to mount asset pack", "pack", p, "error", err)
+			panic(err)
+		}
+	}
+}
+
+// closeAssetPacks closes the files of mounted packs, assets are then only read from
+// the assets file system given at startup
+func (core *runtimeCore) closeAssetPacks() {
+	core.assetsMutex.Lock()
+	defer core.assetsMutex.Unlock()
+	for i := len(core.assetPacks) - 1; i >= 0; i-- {
+		if err := core.assetPacks[i].Close(); err != nil {
+			_logger.Warn("Failed to close asset pack", "error", err)
+		}
+	}
+	core.assetPacks = nil
+	core.assets = core.baseAssets
+}
+
+// assetChanged is called by the desktop target watching assets in development mode, the cache
+// is refreshed and an AssetChangedEvent is published
+func (core *runtimeCore) assetChanged(name string) {
+	core.assetCache.invalidate(name)
+	core.clearVariants()
+	_logger.Debug("Asset changed", "path", name)
+	core.Publish(AssetChangedEvent{Path: name})
+}
+
+// assetPath converts a path given by App or plugins to a valid fs.FS path,
+// leading slashes and relative elements are removed
+func assetPath(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// -------------------------------------------------------------------- //
+// Assets file system
+// -------------------------------------------------------------------- //
+
+// assetFS completes target specific file systems with ReadDir and Stat
+// support based on opened files
+type assetFS struct {
+	fs.FS
+}
+
+func (a assetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(a.FS, name)
+}
+
+func (a assetFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(a.FS, name)
+}
+
+// runtimeAssetFS is the assets file system returned by GetAssetFS, each call is made
+// on the current assets including packs mounted afterwards
+type runtimeAssetFS struct {
+	core *runtimeCore
+}
+
+func (a runtimeAssetFS) Open(name string) (fs.File, error) {
+	return a.core.getAssets().Open(name)
+}
+
+func (a runtimeAssetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(a.core.getAssets(), name)
+}
+
+func (a runtimeAssetFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(a.core.getAssets(), name)
+}
+
+// assetFileInfo is a minimal fs.FileInfo implementation for targets where
+// only the size of assets is known
+type assetFileInfo struct {
+	name string
+	size int64
+}
+
+func (i assetFileInfo) Name() string {
+	return i.name
+}
+
+func (i assetFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i assetFileInfo) Mode() fs.FileMode {
+	return 0444
+}
+
+func (i assetFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i assetFileInfo) IsDir() bool {
+	return false
+}
+
+func (i assetFileInfo) Sys() interface{} {
+	return nil
+}
+
+// overlayFS gives priority to the top file system over the bottom one,
+// directories found in both are merged
+type overlayFS struct {
+	top    fs.FS
+	bottom fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.bottom.Open(name)
+	}
+	return file, err
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	topList, topErr := fs.ReadDir(o.top, name)
+	bottomList, bottomErr := fs.ReadDir(o.bottom, name)
+	if topErr != nil && bottomErr != nil {
+		if errors.Is(topErr, fs.ErrNotExist) {
+			return nil, bottomErr
+		}
+		return nil, topErr
+	}
+	names := make(map[string]bool, len(topList))
+	list := make([]fs.DirEntry, 0, len(topList)+len(bottomList))
+	for _, entry := range topList {
+		names[entry.Name()] = true
+		list = append(list, entry)
+	}
+	for _, entry := range bottomList {
+		if !names[entry.Name()] {
+			list = append(list, entry)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+func (o overlayFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(o.top, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fs.Stat(o.bottom, name)
+	}
+	return info, err
+}
+
+// seekReaderAt implements io.ReaderAt on files which only support seeking
+type seekReaderAt struct {
+	mutex      sync.Mutex
+	readSeeker io.ReadSeeker
+}
+
+func (r *seekReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, err := r.readSeeker.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.readSeeker, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bytes "bytes"
+	binary "encoding/binary"
+	errors "errors"
+	io "io"
+	time "time"
+)
+
+// AudioInfo gives the PCM metadata of an audio asset, it is decoded by LoadAs()
+// from WAV and OGG/Vorbis headers without loading the samples
+type AudioInfo struct {
+	// Format of the asset ("wav" or "vorbis")
+	Format string
+	// SampleRate in Hz
+	SampleRate int
+	// Channels count
+	Channels int
+	// BitsPerSample of the stored samples, 0 for compressed formats
+	BitsPerSample int
+	// Frames count (samples per channel)
+	Frames int64
+	// Duration of the audio
+	Duration time.Duration
+}
+
+// ErrAudioFormat is returned when audio headers are invalid or not supported
+var ErrAudioFormat = errors.New("invalid or unsupported audio format")
+
+// Size of the data read at the end of OGG files to find the last page
+const oggTailSize = 64 * 1024
+
+// decodeWAV reads the RIFF chunks of a WAV file into *AudioInfo targets
+func decodeWAV(reader io.ReadSeeker, target interface{}) error {
+	info, ok := target.(*AudioInfo)
+	if !ok {
+		return ErrUnsupportedTarget
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return ErrAudioFormat
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return ErrAudioFormat
+	}
+
+	blockAlign := 0
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return ErrAudioFormat
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if size < 16 {
+				return ErrAudioFormat
+			}
+			format := make([]byte, 16)
+			if _, err := io.ReadFull(reader, format); err != nil {
+				return ErrAudioFormat
+			}
+			info.Format = "wav"
+			info.Channels = int(binary.LittleEndian.Uint16(format[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+			blockAlign = int(binary.LittleEndian.Uint16(format[12:14]))
+			info.BitsPerSample = int(binary.LittleEndian.Uint16(format[14:16]))
+			size -= 16
+		case "data":
+			if blockAlign == 0 || info.SampleRate == 0 {
+				return ErrAudioFormat
+			}
+			info.Frames = size / int64(blockAlign)
+			info.Duration = time.Duration(info.Frames) * time.Second / time.Duration(info.SampleRate)
+			return nil
+		}
+		// Chunks are padded to even sizes
+		if _, err := reader.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return ErrAudioFormat
+		}
+	}
+}
+
+// decodeOGG reads the Vorbis identification header and the granule position of the
+// last page of an OGG file into *AudioInfo targets
+func decodeOGG(reader io.ReadSeeker, target interface{}) error {
+	info, ok := target.(*AudioInfo)
+	if !ok {
+		return ErrUnsupportedTarget
+	}
+
+	// First page holds the identification header alone
+	page := make([]byte, 27)
+	if _, err := io.ReadFull(reader, page); err != nil || string(page[0:4]) != "OggS" {
+		return ErrAudioFormat
+	}
+	segments := make([]byte, page[26])
+	if _, err := io.ReadFull(reader, segments); err != nil {
+		return ErrAudioFormat
+	}
+	packet := make([]byte, 16)
+	if _, err := io.ReadFull(reader, packet); err != nil {
+		return ErrAudioFormat
+	}
+	if packet[0] != 1 || string(packet[1:7]) != "vorbis" {
+		return ErrAudioFormat
+	}
+	info.Format = "vorbis"
+	info.Channels = int(packet[11])
+	info.SampleRate = int(binary.LittleEndian.Uint32(packet[12:16]))
+	info.BitsPerSample = 0
+	if info.SampleRate == 0 {
+		return ErrAudioFormat
+	}
+
+	// Frames count is the granule position of the last page
+	size, err := reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	offset := size - oggTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	tail := make([]byte, size-offset)
+	if _, err := io.ReadFull(reader, tail); err != nil {
+		return err
+	}
+	last := bytes.LastIndex(tail, []byte("OggS"))
+	if last < 0 || last+14 > len(tail) {
+		return ErrAudioFormat
+	}
+	info.Frames = int64(binary.LittleEndian.Uint64(tail[last+6 : last+14]))
+	info.Duration = time.Duration(info.Frames) * time.Second / time.Duration(info.SampleRate)
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bytes "bytes"
+	binary "encoding/binary"
+	testing "testing"
+	time "time"
+)
+
+// wavChunk returns a RIFF chunk padded to an even size
+func wavChunk(id string, content []byte) []byte {
+	chunk := append([]byte(id), make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(content)))
+	chunk = append(chunk, content...)
+	if len(content)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// wavFmt returns the content of a PCM fmt chunk
+func wavFmt(channels, sampleRate, bitsPerSample int) []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1)
+	binary.LittleEndian.PutUint16(format[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(format[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:], uint32(sampleRate*channels*bitsPerSample/8))
+	binary.LittleEndian.PutUint16(format[12:], uint16(channels*bitsPerSample/8))
+	binary.LittleEndian.PutUint16(format[14:], uint16(bitsPerSample))
+	return format
+}
+
+// wavFile returns a WAV file made of chunks
+func wavFile(chunks ...[]byte) []byte {
+	content := []byte("WAVE")
+	for _, chunk := range chunks {
+		content = append(content, chunk...)
+	}
+	return wavChunk("RIFF", content)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *AudioInfo
+	}{
+		{"stereo", wavFile(wavChunk("fmt ", wavFmt(2, 44100, 16)), wavChunk("data", make([]byte, 44100*4))),
+			&AudioInfo{Format: "wav", SampleRate: 44100, Channels: 2, BitsPerSample: 16, Frames: 44100, Duration: time.Second}},
+		{"extra chunks", wavFile(wavChunk("LIST", []byte("odd")), wavChunk("fmt ", append(wavFmt(1, 8000, 8), 0, 0)),
+			wavChunk("fact", make([]byte, 4)), wavChunk("data", make([]byte, 4000))),
+			&AudioInfo{Format: "wav", SampleRate: 8000, Channels: 1, BitsPerSample: 8, Frames: 4000, Duration: 500 * time.Millisecond}},
+		{"empty", nil, nil},
+		{"not RIFF", append([]byte("RIFX"), wavFile()[4:]...), nil},
+		{"not WAVE", append(wavFile()[:8], []byte("AVI ")...), nil},
+		{"no data", wavFile(wavChunk("fmt ", wavFmt(2, 44100, 16))), nil},
+		{"data before fmt", wavFile(wavChunk("data", make([]byte, 4)), wavChunk("fmt ", wavFmt(2, 44100, 16))), nil},
+		{"short fmt", wavFile(wavChunk("fmt ", make([]byte, 14)), wavChunk("data", make([]byte, 4))), nil},
+		{"zero sample rate", wavFile(wavChunk("fmt ", wavFmt(2, 0, 16)), wavChunk("data", make([]byte, 4))), nil},
+		{"truncated fmt", wavFile(wavChunk("fmt ", wavFmt(2, 44100, 16)))[:30], nil},
+	}
+	for _, test := range tests {
+		info := AudioInfo{}
+		err := decodeWAV(bytes.NewReader(test.data), &info)
+		if test.want == nil {
+			if err != ErrAudioFormat {
+				t.Errorf("%s: decodeWAV() = %v, want ErrAudioFormat", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: decodeWAV() failed: %s", test.name, err)
+		} else if info != *test.want {
+			t.Errorf("%s: decodeWAV() = %+v, want %+v", test.name, info, *test.want)
+		}
+	}
+}
+
+// oggPage returns an OGG page holding packet with granule position
+func oggPage(granule uint64, packet []byte) []byte {
+	page := append([]byte("OggS"), make([]byte, 23)...)
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	page[26] = 1
+	page = append(page, byte(len(packet)))
+	return append(page, packet...)
+}
+
+// vorbisIdentification returns the identification header packet of a Vorbis stream
+func vorbisIdentification(channels int, sampleRate int) []byte {
+	packet := append([]byte("\x01vorbis"), make([]byte, 23)...)
+	packet[11] = byte(channels)
+	binary.LittleEndian.PutUint32(packet[12:], uint32(sampleRate))
+	packet[29] = 1
+	return packet
+}
+
+func TestDecodeOGG(t *testing.T) {
+	identification := oggPage(0, vorbisIdentification(2, 48000))
+	tests := []struct {
+		name string
+		data []byte
+		want *AudioInfo
+	}{
+		{"stream", bytes.Join([][]byte{identification, oggPage(24000, make([]byte, 100)), oggPage(96000, make([]byte, 100))}, nil),
+			&AudioInfo{Format: "vorbis", SampleRate: 48000, Channels: 2, Frames: 96000, Duration: 2 * time.Second}},
+		{"long stream", bytes.Join([][]byte{identification, bytes.Repeat(oggPage(1, make([]byte, 200)), 500), oggPage(480, nil)}, nil),
+			&AudioInfo{Format: "vorbis", SampleRate: 48000, Channels: 2, Frames: 480, Duration: 10 * time.Millisecond}},
+		{"headers only", identification,
+			&AudioInfo{Format: "vorbis", SampleRate: 48000, Channels: 2}},
+		{"empty", nil, nil},
+		{"not OGG", append([]byte("RIFF"), identification[4:]...), nil},
+		{"not Vorbis", oggPage(0, append([]byte("\x01opus.."), make([]byte, 23)...)), nil},
+		{"zero sample rate", oggPage(0, vorbisIdentification(2, 0)), nil},
+		{"truncated", identification[:35], nil},
+	}
+	for _, test := range tests {
+		info := AudioInfo{}
+		err := decodeOGG(bytes.NewReader(test.data), &info)
+		if test.want == nil {
+			if err != ErrAudioFormat {
+				t.Errorf("%s: decodeOGG() = %v, want ErrAudioFormat", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: decodeOGG() failed: %s", test.name, err)
+		} else if info != *test.want {
+			t.Errorf("%s: decodeOGG() = %+v, want %+v", test.name, info, *test.want)
+		}
+	}
+}
+
+func TestDecodeAudioTarget(t *testing.T) {
+	var target []byte
+	if err := decodeWAV(bytes.NewReader(nil), &target); err != ErrUnsupportedTarget {
+		t.Errorf("decodeWAV() = %v, want ErrUnsupportedTarget", err)
+	}
+	if err := decodeOGG(bytes.NewReader(nil), &target); err != ErrUnsupportedTarget {
+		t.Errorf("decodeOGG() = %v, want ErrUnsupportedTarget", err)
+	}
+}
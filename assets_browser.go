@@ -0,0 +1,137 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	errors "errors"
+	io "io"
+	fs "io/fs"
+	path "path"
+	strings "strings"
+	js "syscall/js"
+)
+
+// Minimum size of ranges requested to the server when reading assets
+const browserAssetChunkSize = 64 * 1024
+
+// browserAssetFS gives access to assets served along the application, files are
+// read using HTTP range requests and directories cannot be listed
+type browserAssetFS struct {
+	jsTge *js.Value
+}
+
+func (fsys *browserAssetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	size, err := jsCallAsync(fsys.jsTge, "getAssetSize", name)
+	if err != nil {
+		// Errors are given as "Error: <HTTP status> <status text>"
+		if strings.HasPrefix(err.Error(), "Error: 404 ") {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &browserAssetFile{
+		fsys: fsys,
+		name: name,
+		size: int64(size.Int()),
+	}, nil
+}
+
+func (fsys *browserAssetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotSupported}
+}
+
+// jsCallAsync invokes an asynchronous function of tge.js and waits for its callback,
+// the callback is always called with (result, error) arguments
+func jsCallAsync(jsTge *js.Value, method string, args ...interface{}) (js.Value, error) {
+	var result js.Value
+	var err error
+	doneState := make(chan bool, 1)
+
+	callback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !args[1].IsNull() && !args[1].IsUndefined() {
+			err = errors.New(args[1].String())
+		} else {
+			result = args[0]
+		}
+		doneState <- true
+		return false
+	})
+	defer callback.Release()
+
+	jsTge.Call(method, append(args, callback)...)
+	<-doneState
+
+	return result, err
+}
+
+// browserAssetFile reads an asset by chunks of at least browserAssetChunkSize bytes
+type browserAssetFile struct {
+	fsys        *browserAssetFS
+	name        string
+	size        int64
+	offset      int64
+	buffer      []byte
+	bufferStart int64
+}
+
+func (f *browserAssetFile) Stat() (fs.FileInfo, error) {
+	return assetFileInfo{name: path.Base(f.name), size: f.size}, nil
+}
+
+func (f *browserAssetFile) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if f.offset < f.bufferStart || f.offset >= f.bufferStart+int64(len(f.buffer)) {
+		end := f.offset + int64(len(p))
+		if end < f.offset+browserAssetChunkSize {
+			end = f.offset + browserAssetChunkSize
+		}
+		if end > f.size {
+			end = f.size
+		}
+		content, err := jsCallAsync(f.fsys.jsTge, "loadAssetRange", f.name, f.offset, end)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.buffer = make([]byte, content.Get("length").Int())
+		js.CopyBytesToGo(f.buffer, content)
+		f.bufferStart = f.offset
+		if len(f.buffer) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+	n := copy(p, f.buffer[f.offset-f.bufferStart:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *browserAssetFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *browserAssetFile) Close() error {
+	f.buffer = nil
+	// Releases the full content downloaded if the server ignores ranges
+	f.fsys.jsTge.Call("releaseAsset", f.name)
+	return nil
+}
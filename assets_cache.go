@@ -0,0 +1,240 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	list "container/list"
+	fs "io/fs"
+	sync "sync"
+)
+
+// AssetCacheStats gives the state and the efficiency of the AssetCache
+type AssetCacheStats struct {
+	// Hits and Misses count the lookups of assets in the cache
+	Hits, Misses uint64
+	// Evictions counts the assets removed to stay under the budget
+	Evictions uint64
+	// Entries is the number of cached assets, Pinned the number of pinned ones
+	Entries, Pinned int
+	// Size of the cached assets and Budget of the cache in bytes
+	Size, Budget int64
+}
+
+// AssetCache keeps the content of assets loaded with GetAsset() in memory up to a
+// byte budget, least recently used assets are evicted first. Pinned assets are
+// never evicted until all their pins are released.
+//
+// Contents returned by Get() are shared without copy and must not be modified,
+// GetAsset() returns a copy instead.
+type AssetCache struct {
+	mutex   sync.Mutex
+	load    func(p string) ([]byte, error)
+	budget  int64
+	size    int64
+	entries map[string]*assetCacheEntry
+	lru     *list.List
+	stats   AssetCacheStats
+}
+
+// assetCacheEntry is a cached asset, the element is nil while the asset is pinned
+type assetCacheEntry struct {
+	path    string
+	content []byte
+	refs    int
+	element *list.Element
+}
+
+// newAssetCache creates an AssetCache of budget bytes using load to read assets
+func newAssetCache(budget int64, load func(p string) ([]byte, error)) *AssetCache {
+	return &AssetCache{
+		load:    load,
+		budget:  budget,
+		entries: make(map[string]*assetCacheEntry),
+		lru:     list.New(),
+	}
+}
+
+func (core *runtimeCore) GetAssetCache() *AssetCache {
+	return core.assetCache
+}
+
+// Get returns the content of the asset from the cache, it is loaded if needed. The
+// content is shared with the cache and other callers, it must not be modified.
+func (cache *AssetCache) Get(p string) ([]byte, error) {
+	name := assetPath(p)
+	if content, found := cache.lookup(name, false); found {
+		return content, nil
+	}
+	content, err := cache.load(name)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(name, content, false)
+	return content, nil
+}
+
+// Pin loads the asset if needed and prevents its eviction, each call must be
+// balanced by a call to Release()
+func (cache *AssetCache) Pin(p string) error {
+	name := assetPath(p)
+	if _, found := cache.lookup(name, true); found {
+		return nil
+	}
+	content, err := cache.load(name)
+	if err != nil {
+		return err
+	}
+	cache.put(name, content, true)
+	return nil
+}
+
+// Release removes a pin set by Pin(), the asset becomes evictable once all its
+// pins are released
+func (cache *AssetCache) Release(p string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, found := cache.entries[assetPath(p)]
+	if !found || entry.refs == 0 {
+		return
+	}
+	entry.refs--
+	if entry.refs == 0 {
+		entry.element = cache.lru.PushFront(entry)
+		cache.stats.Pinned--
+		cache.evict()
+	}
+}
+
+// Remove drops the asset from the cache, pinned assets are kept
+func (cache *AssetCache) Remove(p string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if entry, found := cache.entries[assetPath(p)]; found && entry.refs == 0 {
+		cache.remove(entry)
+	}
+}
+
+// Purge drops all the assets which are not pinned
+func (cache *AssetCache) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	for cache.lru.Len() > 0 {
+		cache.remove(cache.lru.Back().Value.(*assetCacheEntry))
+	}
+}
+
+// SetBudget changes the budget of the cache in bytes, 0 disables caching of
+// assets which are not pinned
+func (cache *AssetCache) SetBudget(budget int64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.budget = budget
+	cache.evict()
+}
+
+// Stats returns a snapshot of the cache statistics
+func (cache *AssetCache) Stats() AssetCacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := cache.stats
+	stats.Entries = len(cache.entries)
+	stats.Size = cache.size
+	stats.Budget = cache.budget
+	return stats
+}
+
+// lookup returns the content of a cached asset and updates its position or pins it
+func (cache *AssetCache) lookup(name string, pin bool) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, found := cache.entries[name]
+	if !found {
+		cache.stats.Misses++
+		return nil, false
+	}
+	cache.stats.Hits++
+	if pin {
+		cache.pin(entry)
+	} else if entry.element != nil {
+		cache.lru.MoveToFront(entry.element)
+	}
+	return entry.content, true
+}
+
+// put adds a loaded asset to the cache, if the asset has been added meanwhile
+// the existing entry is kept
+func (cache *AssetCache) put(name string, content []byte, pin bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, found := cache.entries[name]
+	if !found {
+		if !pin && int64(len(content)) > cache.budget {
+			return
+		}
+		entry = &assetCacheEntry{path: name, content: content}
+		entry.element = cache.lru.PushFront(entry)
+		cache.entries[name] = entry
+		cache.size += int64(len(content))
+	}
+	if pin {
+		cache.pin(entry)
+	}
+	cache.evict()
+}
+
+// invalidate drops a modified asset, pinned assets are reloaded in place and keep
+// their previous content if the asset cannot be read anymore
+func (cache *AssetCache) invalidate(name string) {
+	cache.mutex.Lock()
+	entry, found := cache.entries[name]
+	if !found || entry.refs == 0 {
+		if found {
+			cache.remove(entry)
+		}
+		cache.mutex.Unlock()
+		return
+	}
+	cache.mutex.Unlock()
+
+	content, err := cache.load(name)
+	if err != nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries[name] == entry {
+		cache.size += int64(len(content) - len(entry.content))
+		entry.content = content
+		cache.evict()
+	}
+}
+
+// pin increments the references of entry and removes it from the LRU list
+func (cache *AssetCache) pin(entry *assetCacheEntry) {
+	if entry.refs == 0 {
+		cache.lru.Remove(entry.element)
+		entry.element = nil
+		cache.stats.Pinned++
+	}
+	entry.refs++
+}
+
+// evict removes least recently used assets until the size fits in the budget
+func (cache *AssetCache) evict() {
+	for cache.size > cache.budget && cache.lru.Len() > 0 {
+		cache.remove(cache.lru.Back().Value.(*assetCacheEntry))
+		cache.stats.Evictions++
+	}
+}
+
+// remove drops an unpinned entry
+func (cache *AssetCache) remove(entry *assetCacheEntry) {
+	cache.lru.Remove(entry.element)
+	delete(cache.entries, entry.path)
+	cache.size -= int64(len(entry.content))
+}
+
+// readAsset reads an asset without using the cache
+func (core *runtimeCore) readAsset(name string) ([]byte, error) {
+	return fs.ReadFile(core.getAssets(), name)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bytes "bytes"
+	fs "io/fs"
+	reflect "reflect"
+	sort "sort"
+	strconv "strconv"
+	strings "strings"
+	testing "testing"
+)
+
+func TestAssetCache(t *testing.T) {
+	assets := map[string][]byte{
+		"a":   bytes.Repeat([]byte("a"), 4),
+		"b":   bytes.Repeat([]byte("b"), 4),
+		"c":   bytes.Repeat([]byte("c"), 4),
+		"big": bytes.Repeat([]byte("x"), 20),
+	}
+	tests := []struct {
+		name    string
+		budget  int64
+		ops     string
+		entries []string
+		stats   AssetCacheStats
+		loads   int
+	}{
+		{"hit", 8, "get a, get a", []string{"a"},
+			AssetCacheStats{Hits: 1, Misses: 1, Size: 4}, 1},
+		{"least recently used evicted", 8, "get a, get b, get c", []string{"b", "c"},
+			AssetCacheStats{Misses: 3, Evictions: 1, Size: 8}, 3},
+		{"lookup refreshes", 8, "get a, get b, get a, get c", []string{"a", "c"},
+			AssetCacheStats{Hits: 1, Misses: 3, Evictions: 1, Size: 8}, 3},
+		{"over budget not cached", 8, "get big, get big", nil,
+			AssetCacheStats{Misses: 2}, 2},
+		{"pinned not evicted", 8, "pin a, get b, get c", []string{"a", "c"},
+			AssetCacheStats{Misses: 3, Evictions: 1, Pinned: 1, Size: 8}, 3},
+		{"pinned over budget", 8, "pin big, get a", []string{"big"},
+			AssetCacheStats{Misses: 2, Evictions: 1, Pinned: 1, Size: 20}, 2},
+		{"released evicted", 8, "pin big, release big", nil,
+			AssetCacheStats{Misses: 1, Evictions: 1}, 1},
+		{"pins counted", 8, "pin a, pin a, release a, get b, get c", []string{"a", "c"},
+			AssetCacheStats{Hits: 1, Misses: 3, Evictions: 1, Pinned: 1, Size: 8}, 3},
+		{"release without pin", 8, "get a, release a, release missing", []string{"a"},
+			AssetCacheStats{Misses: 1, Size: 4}, 1},
+		{"remove", 8, "get a, pin b, remove a, remove b", []string{"b"},
+			AssetCacheStats{Misses: 2, Pinned: 1, Size: 4}, 2},
+		{"purge", 12, "get a, pin b, get c, purge", []string{"b"},
+			AssetCacheStats{Misses: 3, Pinned: 1, Size: 4}, 3},
+		{"budget reduced", 12, "get a, get b, get c, budget 4", []string{"c"},
+			AssetCacheStats{Misses: 3, Evictions: 2, Size: 4}, 3},
+		{"disabled", 0, "get a, pin b, get a", []string{"b"},
+			AssetCacheStats{Misses: 3, Pinned: 1, Size: 4}, 3},
+		{"paths cleaned", 8, "get ./a, get a", []string{"a"},
+			AssetCacheStats{Hits: 1, Misses: 1, Size: 4}, 1},
+	}
+	for _, test := range tests {
+		loads := 0
+		cache := newAssetCache(test.budget, func(p string) ([]byte, error) {
+			loads++
+			if content, found := assets[p]; found {
+				return content, nil
+			}
+			return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+		})
+		for _, op := range strings.Split(test.ops, ", ") {
+			fields := strings.Fields(op)
+			var err error
+			switch fields[0] {
+			case "get":
+				var content []byte
+				if content, err = cache.Get(fields[1]); err == nil && !bytes.Equal(content, assets[assetPath(fields[1])]) {
+					t.Errorf("%s: Get(%s) returned wrong content", test.name, fields[1])
+				}
+			case "pin":
+				err = cache.Pin(fields[1])
+			case "release":
+				cache.Release(fields[1])
+			case "remove":
+				cache.Remove(fields[1])
+			case "purge":
+				cache.Purge()
+			case "budget":
+				budget, _ := strconv.ParseInt(fields[1], 10, 64)
+				cache.SetBudget(budget)
+			}
+			if err != nil {
+				t.Errorf("%s: %s failed: %s", test.name, op, err)
+			}
+		}
+
+		entries := []string{}
+		for name := range cache.entries {
+			entries = append(entries, name)
+		}
+		sort.Strings(entries)
+		if len(entries) != len(test.entries) || (len(entries) > 0 && !reflect.DeepEqual(entries, test.entries)) {
+			t.Errorf("%s: cached %v, want %v", test.name, entries, test.entries)
+		}
+		want := test.stats
+		want.Entries, want.Budget = len(test.entries), cache.budget
+		if stats := cache.Stats(); stats != want {
+			t.Errorf("%s: Stats() = %+v, want %+v", test.name, stats, want)
+		}
+		if loads != test.loads {
+			t.Errorf("%s: %d loads, want %d", test.name, loads, test.loads)
+		}
+	}
+}
+
+func TestAssetCacheMissing(t *testing.T) {
+	cache := newAssetCache(8, func(p string) ([]byte, error) {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	})
+	if _, err := cache.Get("missing"); err == nil {
+		t.Error("Get(missing) should fail")
+	}
+	if err := cache.Pin("missing"); err == nil {
+		t.Error("Pin(missing) should fail")
+	}
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Pinned != 0 {
+		t.Errorf("Stats() = %+v, want no entries", stats)
+	}
+}
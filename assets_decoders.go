@@ -0,0 +1,138 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	image "image"
+	_ "image/gif"  // GIF support in image.Decode
+	_ "image/jpeg" // JPEG support in image.Decode
+	_ "image/png"  // PNG support in image.Decode
+	io "io"
+	fs "io/fs"
+	ioutil "io/ioutil"
+	mime "mime"
+	path "path"
+	strings "strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ErrNoDecoder is returned by LoadAs() when no decoder is registered for an asset
+var ErrNoDecoder = errors.New("no decoder registered for asset")
+
+// ErrUnsupportedTarget is returned by decoders when the type of the target is not supported
+var ErrUnsupportedTarget = errors.New("unsupported decoding target")
+
+// Decoder decodes the content of an asset available in reader into target, target
+// is always a pointer and ErrUnsupportedTarget must be returned if its type is not
+// supported by the decoder
+type Decoder func(reader io.ReadSeeker, target interface{}) error
+
+// Decoders available in all Runtimes indexed by extension and MIME type
+var defaultDecoders = map[string]Decoder{
+	".png":               decodeImage,
+	".jpg":               decodeImage,
+	".jpeg":              decodeImage,
+	".gif":               decodeImage,
+	"image/png":          decodeImage,
+	"image/jpeg":         decodeImage,
+	"image/gif":          decodeImage,
+	".json":              decodeJSON,
+	"application/json":   decodeJSON,
+	".yaml":              decodeYAML,
+	".yml":               decodeYAML,
+	"application/yaml":   decodeYAML,
+	"application/x-yaml": decodeYAML,
+	"text/yaml":          decodeYAML,
+	".wav":               decodeWAV,
+	"audio/wav":          decodeWAV,
+	"audio/wave":         decodeWAV,
+	"audio/x-wav":        decodeWAV,
+	".ogg":               decodeOGG,
+	"audio/ogg":          decodeOGG,
+}
+
+func (core *runtimeCore) RegisterDecoder(decoder Decoder, keys ...string) {
+	core.decodersMutex.Lock()
+	defer core.decodersMutex.Unlock()
+	for _, key := range keys {
+		core.decoders[strings.ToLower(key)] = decoder
+	}
+}
+
+func (core *runtimeCore) LoadAs(p string, target interface{}) error {
+	decoder := core.findDecoder(p)
+	if decoder == nil {
+		return &fs.PathError{Op: "decode", Path: p, Err: ErrNoDecoder}
+	}
+	file, err := core.OpenAsset(p)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := decoder(file, target); err != nil {
+		return &fs.PathError{Op: "decode", Path: p, Err: err}
+	}
+	return nil
+}
+
+// findDecoder returns the decoder of the asset based on its extension first then
+// on its MIME type
+func (core *runtimeCore) findDecoder(p string) Decoder {
+	core.decodersMutex.RLock()
+	defer core.decodersMutex.RUnlock()
+	ext := strings.ToLower(path.Ext(p))
+	if decoder, found := core.decoders[ext]; found {
+		return decoder
+	}
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil {
+		return core.decoders[mediaType]
+	}
+	return nil
+}
+
+// initDecoders fills the registry with default decoders
+func (core *runtimeCore) initDecoders() {
+	core.decoders = make(map[string]Decoder, len(defaultDecoders))
+	for key, decoder := range defaultDecoders {
+		core.decoders[key] = decoder
+	}
+}
+
+// -------------------------------------------------------------------- //
+// Default decoders
+// -------------------------------------------------------------------- //
+
+// decodeImage decodes PNG, JPEG and GIF images into *image.Image targets
+func decodeImage(reader io.ReadSeeker, target interface{}) error {
+	img, ok := target.(*image.Image)
+	if !ok {
+		return ErrUnsupportedTarget
+	}
+	decoded, _, err := image.Decode(reader)
+	if err != nil {
+		return err
+	}
+	*img = decoded
+	return nil
+}
+
+// decodeJSON decodes JSON content into any target supported by json.Unmarshal
+func decodeJSON(reader io.ReadSeeker, target interface{}) error {
+	return json.NewDecoder(reader).Decode(target)
+}
+
+// decodeYAML decodes YAML content into any target supported by yaml.Unmarshal
+func decodeYAML(reader io.ReadSeeker, target interface{}) error {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("invalid YAML: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build (darwin || freebsd || linux || windows) && !android && !ios && !js
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package tge
+
+import (
+	fs "io/fs"
+	filepath "path/filepath"
+	time "time"
+)
+
+// Interval between two scans of the assets directory in development mode
+const assetsWatchInterval = 500 * time.Millisecond
+
+// assetState is the last known state of a watched asset
+type assetState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchAssets scans the assets directory until stop is closed and notifies
+// the core of each modified, created or removed file
+func (core *runtimeCore) watchAssets(dir string, stop <-chan struct{}) {
+	_logger.Info("Watching assets", "dir", dir)
+	states := scanAssets(dir)
+	ticker := time.NewTicker(assetsWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			current := scanAssets(dir)
+			for name, state := range current {
+				if previous, found := states[name]; !found || previous != state {
+					core.assetChanged(name)
+				}
+			}
+			for name := range states {
+				if _, found := current[name]; !found {
+					core.assetChanged(name)
+				}
+			}
+			states = current
+		}
+	}
+}
+
+// scanAssets returns the state of all files of dir indexed by asset path
+func scanAssets(dir string) map[string]assetState {
+	states := make(map[string]assetState)
+	filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if name, err := filepath.Rel(dir, p); err == nil {
+			states[filepath.ToSlash(name)] = assetState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return states
+}
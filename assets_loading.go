@@ -0,0 +1,211 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	context "context"
+	io "io"
+	fs "io/fs"
+	sync "sync"
+	atomic "sync/atomic"
+)
+
+// Maximum number of assets loaded in parallel by LoadAssetAsync()
+const assetLoadingConcurrency = 4
+
+// Size of chunks read between two AssetProgressEvent
+const assetLoadingChunkSize = 32 * 1024
+
+// AssetProgressEvent is published on "asset" channel during an asynchronous loading started
+// with LoadAssetAsync(), aggregate values cover all the assets of the loading
+type AssetProgressEvent struct {
+	// Path of the asset as given to LoadAssetAsync()
+	Path string
+	// Loaded and Total bytes of the asset
+	Loaded, Total int64
+	// AllLoaded and AllTotal bytes of the whole loading
+	AllLoaded, AllTotal int64
+	// Err is set if the asset failed to load, no more events are sent for it
+	Err error
+}
+
+// Channel of AssetProgressEvent = "asset"
+func (e AssetProgressEvent) Channel() string {
+	return "asset"
+}
+
+// AssetLoading is the handle of an asynchronous loading started with LoadAssetAsync()
+type AssetLoading struct {
+	paths    []string
+	done     chan struct{}
+	cancel   context.CancelFunc
+	mutex    sync.Mutex
+	contents map[string][]byte
+	errors   map[string]error
+	loaded   int64
+	total    int64
+}
+
+func (core *runtimeCore) LoadAssetAsync(paths ...string) *AssetLoading {
+	ctx, cancel := context.WithCancel(context.Background())
+	loading := &AssetLoading{
+		paths:    paths,
+		done:     make(chan struct{}),
+		cancel:   cancel,
+		contents: make(map[string][]byte, len(paths)),
+		errors:   make(map[string]error),
+	}
+	go loading.run(ctx, core)
+	return loading
+}
+
+// Done returns a channel closed when all assets are loaded or failed
+func (loading *AssetLoading) Done() <-chan struct{} {
+	return loading.done
+}
+
+// Cancel stops the loading, assets not yet loaded are failed with context.Canceled
+func (loading *AssetLoading) Cancel() {
+	loading.cancel()
+}
+
+// Wait blocks until the end of the loading and returns the first error in paths order
+func (loading *AssetLoading) Wait() error {
+	<-loading.done
+	loading.mutex.Lock()
+	defer loading.mutex.Unlock()
+	for _, p := range loading.paths {
+		if err, found := loading.errors[p]; found {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns a copy of the content of a loaded asset, it blocks until the end of the loading
+func (loading *AssetLoading) Get(p string) ([]byte, error) {
+	<-loading.done
+	loading.mutex.Lock()
+	defer loading.mutex.Unlock()
+	if err, found := loading.errors[p]; found {
+		return nil, err
+	}
+	if content, found := loading.contents[p]; found {
+		// The content is shared with the asset cache
+		return append([]byte(nil), content...), nil
+	}
+	return nil, &fs.PathError{Op: "load", Path: p, Err: fs.ErrNotExist}
+}
+
+// Progress returns the loaded and total bytes of the whole loading
+func (loading *AssetLoading) Progress() (loaded int64, total int64) {
+	return atomic.LoadInt64(&loading.loaded), atomic.LoadInt64(&loading.total)
+}
+
+func (loading *AssetLoading) run(ctx context.Context, core *runtimeCore) {
+	defer close(loading.done)
+	defer loading.cancel()
+
+	// Sizes are retrieved first to give an accurate AllTotal in events
+	sizes := make([]int64, len(loading.paths))
+	loading.forEach(func(i int, p string) {
+		name, err := core.resolveAsset(p)
+		if err != nil {
+			loading.fail(core, p, err)
+			return
+		}
+		info, err := fs.Stat(core.getAssets(), name)
+		if err != nil {
+			loading.fail(core, p, err)
+			return
+		}
+		sizes[i] = info.Size()
+		atomic.AddInt64(&loading.total, info.Size())
+	})
+
+	loading.forEach(func(i int, p string) {
+		if !loading.isFailed(p) {
+			loading.load(ctx, core, p, sizes[i])
+		}
+	})
+}
+
+// forEach calls fn on each path with at most assetLoadingConcurrency calls in parallel
+func (loading *AssetLoading) forEach(fn func(i int, p string)) {
+	var waitGroup sync.WaitGroup
+	semaphore := make(chan struct{}, assetLoadingConcurrency)
+	for i, p := range loading.paths {
+		waitGroup.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, p string) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			fn(i, p)
+		}(i, p)
+	}
+	waitGroup.Wait()
+}
+
+func (loading *AssetLoading) load(ctx context.Context, core *runtimeCore, p string, size int64) {
+	name, err := core.resolveAsset(p)
+	if err != nil {
+		loading.fail(core, p, err)
+		return
+	}
+	file, err := core.getAssets().Open(name)
+	if err != nil {
+		loading.fail(core, p, err)
+		return
+	}
+	defer file.Close()
+
+	content := make([]byte, 0, size)
+	chunk := make([]byte, assetLoadingChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			loading.fail(core, p, err)
+			return
+		}
+		n, err := file.Read(chunk)
+		if n > 0 {
+			content = append(content, chunk[:n]...)
+			core.Publish(AssetProgressEvent{
+				Path:      p,
+				Loaded:    int64(len(content)),
+				Total:     size,
+				AllLoaded: atomic.AddInt64(&loading.loaded, int64(n)),
+				AllTotal:  atomic.LoadInt64(&loading.total),
+			})
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			loading.fail(core, p, err)
+			return
+		}
+	}
+
+	core.assetCache.put(name, content, false)
+	loading.mutex.Lock()
+	defer loading.mutex.Unlock()
+	loading.contents[p] = content
+}
+
+func (loading *AssetLoading) fail(core *runtimeCore, p string, err error) {
+	loading.mutex.Lock()
+	loading.errors[p] = err
+	loading.mutex.Unlock()
+	core.Publish(AssetProgressEvent{
+		Path:      p,
+		AllLoaded: atomic.LoadInt64(&loading.loaded),
+		AllTotal:  atomic.LoadInt64(&loading.total),
+		Err:       err,
+	})
+}
+
+func (loading *AssetLoading) isFailed(p string) bool {
+	loading.mutex.Lock()
+	defer loading.mutex.Unlock()
+	_, found := loading.errors[p]
+	return found
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	errors "errors"
+	fs "io/fs"
+	testing "testing"
+	fstest "testing/fstest"
+)
+
+func TestAssetLoadingGet(t *testing.T) {
+	core := &runtimeCore{}
+	if err := core.initCore(&options{}); err != nil {
+		t.Fatal(err)
+	}
+	core.initAssets(fstest.MapFS{"a.txt": &fstest.MapFile{Data: []byte("content")}}, defaultSettings)
+
+	loading := core.LoadAssetAsync("a.txt", "missing.txt")
+	if err := loading.Wait(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Wait() = %v, want fs.ErrNotExist", err)
+	}
+	content, err := loading.Get("a.txt")
+	if err != nil || string(content) != "content" {
+		t.Fatalf("Get(a.txt) = %q, %v, want content", content, err)
+	}
+
+	// Contents are copies of the cached ones
+	content[0] = 'X'
+	if again, _ := loading.Get("a.txt"); string(again) != "content" {
+		t.Errorf("Get(a.txt) = %q after modification, want content", again)
+	}
+	if cached, _ := core.assetCache.Get("a.txt"); string(cached) != "content" {
+		t.Errorf("cached content = %q after modification, want content", cached)
+	}
+	if _, err := loading.Get("other.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get(other.txt) = %v, want fs.ErrNotExist", err)
+	}
+}
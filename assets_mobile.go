@@ -0,0 +1,60 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android || ios
+// +build android ios
+
+package tge
+
+import (
+	errors "errors"
+	fmt "fmt"
+	io "io"
+	fs "io/fs"
+	path "path"
+
+	asset "github.com/thommil/tge-mobile/asset"
+)
+
+// mobileAssetFS gives access to assets packaged in the application, directories
+// cannot be opened as the underlying asset manager only handles files
+type mobileAssetFS struct{}
+
+func (fsys mobileAssetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	file, err := asset.Open(name)
+	if err != nil {
+		// The asset manager of Android reports missing files as "bad asset"
+		if !errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("%v: %w", err, fs.ErrNotExist)
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &mobileAssetFile{File: file, name: name}, nil
+}
+
+func (fsys mobileAssetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotSupported}
+}
+
+// mobileAssetFile adds fs.File support to asset.File
+type mobileAssetFile struct {
+	asset.File
+	name string
+}
+
+func (f *mobileAssetFile) Stat() (fs.FileInfo, error) {
+	current, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	if _, err = f.Seek(current, io.SeekStart); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	return assetFileInfo{name: path.Base(f.name), size: size}, nil
+}
@@ -26,10 +26,50 @@ not portable:
 The App interface is described here and the implementation details in the auto
 generated app.go using tge-cli.
 
+Settings
+
+The Settings given to App.OnCreate() are loaded from the following sources, each one overriding
+the previous ones:
+ - default values
+ - settings.json or settings.yaml file in assets
+ - settings.json or settings.yaml file in user data (see GetUserFS())
+ - environment variables prefixed by TGE_ (TGE_WIDTH=800)
+ - command line flags on desktop (-tge.width=800) or URL query on browser (?tge.width=800)
+
+User data is located with the Settings.Name given by the sources above, the name is not
+read from the user file. As the user file is read before App.OnCreate(), a name changed by
+App.OnCreate() does not move user data, the name of the App should be set in the settings
+file of assets.
+
+Keys are the JSON names of Settings fields, lists are comma separated in environment variables,
+flags and URL query.
+
+The App configuration can be stored in the custom section of the same files, nested sections
+are merged across sources. Values are overridden with TGE_CUSTOM_<KEY> environment variables
+(nested keys are separated by a double underscore, TGE_CUSTOM_SERVER__URL), -tge.custom key=value
+flags or ?tge.custom.<key>=value URL query. Overridden values are read as integers, floats or
+booleans (true and false only), other values are strings. Values are decoded in App types with
+DecodeCustom():
+
+ # settings.yaml
+ custom:
+   difficulty: hard
+   server:
+     url: https://example.com
+
+ var server ServerConfig
+ err := runtime.GetSettings().DecodeCustom("server", &server)
+
+Settings are checked by Settings.Validate() after App.OnCreate(), invalid values stop the Runtime
+with an error describing each of them. The window size is reduced to fit the display if needed.
+On desktop, the window size, position and fullscreen mode changed by the user are saved in the
+settings file of user data at exit and restored at next launch before App.OnCreate().
+
 Runtime
 
-The Runtime instance is initialized through the Run(*App) function of main package. At
-startup, the Runtime looks for registered plugins and initializes them. Then the
+The Runtime instance is created and initialized through the Run(App, ...Option) function of
+main package, each call creates its own Runtime. At startup, the Runtime looks for registered
+plugins and the ones given in options and initializes them. Then the
 App instance is initialized and started.
 
 The Runtime instance also exposes API for loading assets and subscribing to events
@@ -49,6 +89,107 @@ implementations are as follows:
   - android/ios  : gl.Context     - Custom gomobile from https://github.com/thommil/tge-mobile
   - browser      : *js.Value      - WebGL/WebGL2 context through WebAssembly from Go 1.12
 
+Assets
+
+Assets are always stored in the package assets folder independently of the target. They can be
+loaded at once with GetAsset() or streamed with OpenAsset(), which should be preferred for large
+files (music, video ...). The whole assets tree is also available as a read-only fs.FS using
+GetAssetFS():
+
+ file, err := runtime.OpenAsset("music/theme.ogg")
+ if err != nil {
+	 return err
+ }
+ defer file.Close()
+
+Contents loaded by GetAsset() are kept in an LRU cache whose budget is set by
+Settings.AssetCacheSize, GetAsset() always returns a copy that the caller can modify. Read-only
+users can avoid the copy by getting the shared content from the cache. Frequently used assets
+can be pinned to avoid their eviction and the cache statistics help to tune the budget:
+
+ cache := runtime.GetAssetCache()
+ cache.Pin("shaders/main.frag")
+ defer cache.Release("shaders/main.frag")
+ source, err := cache.Get("shaders/main.frag") // shared, must not be modified
+ ...
+ stats := cache.Stats()
+
+Loading screens can rely on LoadAssetAsync() which loads assets in background and publishes
+AssetProgressEvent on the "asset" channel, AssetLoading.Get() returns a copy as GetAsset():
+
+ loading := runtime.LoadAssetAsync("textures/level1.png", "music/level1.ogg")
+ ...
+ <-loading.Done()
+ texture, err := loading.Get("textures/level1.png")
+
+Assets can be decoded directly using LoadAs(), the decoder is selected by the asset extension
+or MIME type. PNG/JPEG/GIF images are decoded into image.Image, JSON/YAML into any struct and
+WAV/OGG headers into AudioInfo. Plugins can add their own formats with RegisterDecoder():
+
+ var img image.Image
+ err := runtime.LoadAs("textures/player.png", &img)
+
+Assets paths can contain placeholders resolved according to the locale (GetLocale()) and the pixel
+density (GetPixelDensity()) of the platform, allowing a single release for all languages and screens.
+Variants are searched from the most specific to the default asset, the fallback locale is set by
+Settings.FallbackLocale. Paths without placeholders are used as is:
+
+ strings/{locale}.json  : strings/fr-FR.json, strings/fr.json, strings/en.json
+ ui/title{density}.png  : ui/title@3x.png, ui/title@2x.png, ui/title.png
+ ui/{locale}/logo.png   : ui/fr-FR/logo.png, ui/fr/logo.png, ui/en/logo.png
+
+To limit the number of files to ship (and browser requests), assets can be gathered in packs
+built with the github.com/thommil/tge/pack package. Packs listed in Settings.AssetPacks are
+mounted at startup (or later using MountAssetPack()) and their entries become available
+transparently through all the assets functions:
+
+ // Build
+ pack.BuildDir("assets", "dist/assets/data.tgepack")
+
+ // App
+ settings.AssetPacks = []string{"data.tgepack"}
+
+On browser, assets are read using HTTP range requests and, like on mobile, directories cannot be
+listed.
+
+During development, modified assets are reloaded in the cache and an AssetChangedEvent is
+published on the "asset" channel. This is only enabled on desktop when assets are read from the
+App source tree (../../assets).
+
+On desktop, the assets folder is searched relatively to the executable by default. Another folder
+can be given with Settings.AssetsDir, the WithAssetsDir() option or the TGE_ASSETS_DIR environment
+variable which overrides all others. Assets can also be embedded in the binary for single file
+distribution using the WithAssets() option, available on all targets:
+
+ //go:embed assets
+ var embedded embed.FS
+
+ assets, _ := fs.Sub(embedded, "assets")
+ tge.Run(app, tge.WithAssets(assets))
+
+Storage
+
+GetStorage() gives access to a persistent key-value store of the App, allowing to save settings
+or progress the same way on all targets. Values are stored in the App folder of the OS config
+directory on desktop, in the App files directory on mobile and in localStorage on browser. Changes
+are persisted atomically when calling Commit():
+
+ storage := runtime.GetStorage()
+ storage.Set("progress/level", []byte("3"))
+ if err := storage.Commit(); err != nil {
+	 storage.Rollback()
+ }
+
+Files like save games, screenshots or logs can be written in the user data directory of the App
+using GetUserFS(). It is located in XDG_DATA_HOME on Linux, AppData on Windows, Application Support
+on macOS, the App files directory on mobile and IndexedDB on browser. Paths are relative to the root
+of this directory and cannot escape it, Settings.UserFSQuota limits its size:
+
+ userFS := runtime.GetUserFS()
+ err := userFS.WriteFile("saves/slot1.sav", content)
+ ...
+ used, quota, err := userFS.Usage()
+
 Rendering
 
 TGE uses Go channel mechanism to handle rendering, two loops are running side by side:
@@ -77,11 +218,100 @@ are then propagated through publish/subscribe:
  Unsubscribe(channel string, listener Listener)
  Publish(event Event)
 
-Events are in their raw form (ie modifiers or gestures are not handled). It's up to the
+Events are in their raw form (ie modifiers are not handled). It's up to the
 application to implement specific needs. The aim of this approach is to keep the runtime
 generic and fast by limiting treatments.
 
-A dedicated plugin to generate advanced events will be available soon.
+The events received are selected by Settings.EventMask and can be changed at any time using
+SetEventMask(), for instance to receive text input only while a text field is focused:
+
+ runtime.SetEventMask(tge.KeyEventEnabled | tge.TextInputEventEnabled | tge.MouseButtonEventEnabled)
+
+Touches are published as MouseEvent with TouchFirst, TouchSecond and TouchThird buttons when
+TouchEventEnabled is set, or like mouse events when MouseButtonEventEnabled (down and up) or
+MouseMotionEventEnabled (move) is set. TextInputEvent are published on the "text" channel,
+GamepadEvent on the "gamepad" channel (desktop and browser) and ResizeEvent is always published.
+On browser, mouse and touches are read from Pointer Events, positions are relative to the canvas
+in pixels of its drawing buffer and touches follow the same sequence as on mobile, a cancelled
+touch being published as TypeUp.
+
+On mobile and browser, the virtual keyboard is shown with ShowKeyboard() and hidden with
+HideKeyboard(), the typed text is published as TextInputEvent and editing keys (backspace,
+enter, arrows) as KeyEvent. The KeyboardType selects the layout on browser and iOS (only
+backspace and enter are sent as KeyEvent), Android uses the default one:
+
+ runtime.ShowKeyboard(tge.KeyboardEmail)
+
+The state of input devices can also be polled with GetInput(), it returns a snapshot taken
+before each OnTick() with the keys, buttons and touches down, the mouse position and the keys
+and buttons pressed or released since the previous OnTick(). Keys and buttons down are released
+when the App loses the focus:
+
+ input := runtime.GetInput()
+ if input.IsKeyPressed(tge.KeyCodeEscape) {
+	 ...
+ }
+
+Instead of checking keys in listeners, Apps can declare actions bound to keys, buttons, touches
+or gamepad axes in Settings.Actions and poll them with GetActions(), they are read from the same
+snapshot as GetInput(). Bindings can be changed at runtime and saved in the settings file of
+user data:
+
+ # settings.yaml
+ actions:
+   jump: [key:Spacebar, gamepad:A]
+   move_x: [key:RightArrow, -key:LeftArrow, axis:LeftX]
+
+ func (app *App) OnTick(elapsedTime time.Duration, syncChan chan<- interface{}) {
+	 actions := app.runtime.GetActions()
+	 if actions.IsPressed("jump") {
+		 ...
+	 }
+	 app.player.X += actions.Value("move_x") * speed
+ }
+
+When GestureEventEnabled is set, touches and left mouse button are also recognized as taps,
+double taps, long presses, swipes, pans, pinches and rotations published as GestureEvent on the
+"gesture" channel. Continuous gestures (pan, pinch, rotate) are sent with TypeDown, TypeMove
+and TypeUp, long presses are recognized at the beginning of ticks. Thresholds can be tuned with
+SetGestureThresholds():
+
+ runtime.Subscribe(tge.GestureEvent{}.Channel(), func(event tge.Event) bool {
+	 if gesture := event.(tge.GestureEvent); gesture.Gesture == tge.GesturePinch {
+		 app.camera.Zoom(gesture.Scale)
+	 }
+	 return false
+ })
+
+Recording
+
+Input events (mouse, touch, scroll, key, text and gamepad) can be recorded with the tick which
+received them to reproduce a session, for instance to attach a recording to a bug report. The
+recording is written in JSON lines using the WithRecording() option or in the file of user data
+given by Settings.Record (-tge.record=bug.tgerec). It is replayed in a new Run using WithReplay()
+or Settings.Replay, events are then published before the same ticks with the same elapsed time
+and live input events are ignored until the end of the recording:
+
+ file, _ := os.Create("session.tgerec")
+ defer file.Close()
+ tge.Run(&MyApp{}, tge.WithRecording(file))
+ ...
+ file, _ := os.Open("session.tgerec")
+ tge.Run(&MyApp{}, tge.WithReplay(file))
+
+Other events, like gestures, are produced again by the Runtime from the replayed ones, using the
+recorded times of events and ticks instead of the current time.
+
+Logging
+
+The Runtime writes its messages through a leveled Logger available with GetLogger(), each
+message can be completed by key-value fields:
+
+ runtime.GetLogger().Info("Level loaded", "name", name, "duration", elapsed)
+
+Default implementations write on standard error on desktop/ios, in logcat on android and in the
+console on browser. The minimum level is set by Settings.LogLevel and the default Logger can be
+replaced before Run() using SetLogger(), a replaced Logger keeps its own level.
 
 Plugins
 
@@ -97,6 +327,11 @@ standard Go packages, ex:
 	 gl.ClearColor(0, 0, 0, 1)
  }
 
+Plugins can also be enabled for a single Runtime by passing them to Run(), this way
+they can be chosen at startup without relying on imports side effects:
+
+ tge.Run(&MyApp{}, tge.WithPlugins(myplugin.New()))
+
 It's also possible to create custom plugins by implementing Plugin interface and
 registering it in the Go init() function :
 
@@ -127,5 +362,31 @@ registering it in the Go init() function :
 	 // Dispose code HERE if needed
  }
 
+Plugins are initialized by name order, a plugin relying on other ones must implement the
+DependentPlugin interface to be initialized after them (and disposed before them):
+
+ func (p *plugin) Dependencies() []string {
+	return []string{"gl"}
+ }
+
+A missing dependency or a dependency cycle stops the Runtime at startup.
+
+Plugins can also follow the Runtime lifecycle by implementing the optional interfaces Pausable,
+Resumable, Resizable and FrameHook, the Runtime detects them and calls them at the right points of its
+loops without any forwarding from the App.
+
+Registered plugins can be retrieved using Runtime.GetPlugin(name). To expose capabilities
+independently of their implementation, plugins can also publish services in the Runtime registry
+during Init() and App or other plugins can then discover them:
+
+ // In plugin
+ runtime.RegisterService("audio", audioService)
+
+ // In App or other plugin
+ var audio AudioService
+ if err := runtime.GetService("audio", &audio); err != nil {
+	 // audio not available
+ }
+
 */
 package tge // import "github.com/thommil/tge"
@@ -0,0 +1,420 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	math "math"
+	sync "sync"
+	time "time"
+)
+
+// -------------------------------------------------------------------- //
+// Gesture events
+// -------------------------------------------------------------------- //
+
+// GestureType identifies a gesture recognized from touches or mouse
+type GestureType byte
+
+// GestureType values
+const (
+	// GestureTap short touch without motion
+	GestureTap GestureType = iota + 1
+	// GestureDoubleTap second tap close in time and position to the previous one
+	GestureDoubleTap
+	// GestureLongPress touch held without motion
+	GestureLongPress
+	// GestureSwipe fast motion released quickly, DX/DY give the direction
+	GestureSwipe
+	// GesturePinch two fingers moving closer or further, Scale is relative to the beginning
+	GesturePinch
+	// GestureRotate two fingers turning, Angle is in radians relative to the beginning
+	GestureRotate
+	// GesturePan single finger motion, DX/DY give the translation since the beginning
+	GesturePan
+)
+
+// String is Stringer implementation of GestureType
+func (g GestureType) String() string {
+	switch g {
+	case GestureTap:
+		return "GestureTap"
+	case GestureDoubleTap:
+		return "GestureDoubleTap"
+	case GestureLongPress:
+		return "GestureLongPress"
+	case GestureSwipe:
+		return "GestureSwipe"
+	case GesturePinch:
+		return "GesturePinch"
+	case GestureRotate:
+		return "GestureRotate"
+	case GesturePan:
+		return "GesturePan"
+	}
+	return "unknown"
+}
+
+// GestureEvent is triggered when a gesture is recognized from "mouse" events, touches
+// and left mouse button are handled the same way on all targets. Continuous gestures
+// (pan, pinch, rotate) are published with TypeDown when they begin, TypeMove while
+// they change and TypeUp when they end, other gestures use TypeNone. X/Y is the
+// position of the gesture (center of the fingers for pinch and rotate).
+type GestureEvent struct {
+	Gesture  GestureType
+	Type     Type
+	X, Y     int32
+	DX, DY   int32
+	Scale    float32
+	Angle    float32
+	Velocity float32
+}
+
+// Channel of GestureEvent = "gesture"
+func (e GestureEvent) Channel() string {
+	return "gesture"
+}
+
+// GestureThresholds defines the limits used to recognize gestures, distances are in
+// pixels
+type GestureThresholds struct {
+	// TapMaxDuration is the maximum duration of a tap
+	TapMaxDuration time.Duration
+	// TapSlop is the maximum motion of a tap or a long press
+	TapSlop int32
+	// DoubleTapDelay is the maximum delay between the taps of a double tap
+	DoubleTapDelay time.Duration
+	// LongPressDuration is the minimum duration of a long press
+	LongPressDuration time.Duration
+	// SwipeMinDistance is the minimum motion of a swipe
+	SwipeMinDistance int32
+	// SwipeMinVelocity is the minimum velocity of a swipe in pixels per second
+	SwipeMinVelocity float32
+	// PinchMinScale is the scale change beginning a pinch
+	PinchMinScale float32
+	// RotateMinAngle is the angle in radians beginning a rotation
+	RotateMinAngle float32
+}
+
+// DefaultGestureThresholds are the thresholds used if not set with SetGestureThresholds()
+var DefaultGestureThresholds = GestureThresholds{
+	TapMaxDuration:    300 * time.Millisecond,
+	TapSlop:           10,
+	DoubleTapDelay:    300 * time.Millisecond,
+	LongPressDuration: 500 * time.Millisecond,
+	SwipeMinDistance:  50,
+	SwipeMinVelocity:  300,
+	PinchMinScale:     0.05,
+	RotateMinAngle:    0.1,
+}
+
+func (core *runtimeCore) SetGestureThresholds(thresholds GestureThresholds) {
+	core.gestures.mutex.Lock()
+	defer core.gestures.mutex.Unlock()
+	core.gestures.thresholds = thresholds
+}
+
+// -------------------------------------------------------------------- //
+// Recognizer
+// -------------------------------------------------------------------- //
+
+// gestureRecognizer publishes GestureEvent from MouseEvent, a gesture sequence starts
+// with the first finger down and ends when all fingers are up
+type gestureRecognizer struct {
+	mutex      sync.Mutex
+	thresholds GestureThresholds
+	publish    func(event Event)
+	pointers   map[Button]*gesturePointer
+	mouseDown  bool
+	fingers    int
+	start      time.Time
+	longPress  time.Time
+	pressed    bool
+	panning    bool
+	pinching   bool
+	rotating   bool
+	pair       [2]*gesturePointer
+	distance0  float64
+	angle0     float64
+	lastTap    time.Time
+	lastTapX   int32
+	lastTapY   int32
+}
+
+// gesturePointer is a finger (or the left mouse button) down
+type gesturePointer struct {
+	startX, startY int32
+	x, y           int32
+}
+
+func newGestureRecognizer(publish func(event Event)) *gestureRecognizer {
+	return &gestureRecognizer{
+		thresholds: DefaultGestureThresholds,
+		publish:    publish,
+		pointers:   make(map[Button]*gesturePointer),
+	}
+}
+
+// update feeds the recognizer with a MouseEvent received at now, other events are ignored
+func (g *gestureRecognizer) update(event Event, now time.Time) {
+	e, ok := event.(MouseEvent)
+	if !ok {
+		return
+	}
+	g.mutex.Lock()
+	events := g.handle(e, now)
+	g.mutex.Unlock()
+	for _, event := range events {
+		g.publish(event)
+	}
+}
+
+// handle updates the sequence with e and returns the recognized gestures
+func (g *gestureRecognizer) handle(e MouseEvent, now time.Time) []Event {
+	// Left mouse button emulates the first finger
+	button := e.Button
+	switch {
+	case button&touchButtons != 0:
+	case button == ButtonLeft:
+		button = TouchFirst
+		g.mouseDown = e.Type == TypeDown
+	case button == ButtonNone && e.Type == TypeMove && g.mouseDown:
+		button = TouchFirst
+	default:
+		return nil
+	}
+
+	switch e.Type {
+	case TypeDown:
+		return g.down(button, e.X, e.Y, now)
+	case TypeMove:
+		if pointer, found := g.pointers[button]; found {
+			pointer.x, pointer.y = e.X, e.Y
+			return g.move()
+		}
+	case TypeUp:
+		if pointer, found := g.pointers[button]; found {
+			// A long press elapsed since the last tick is published before the release
+			events := []Event{}
+			if event, ok := g.longPressed(now); ok {
+				events = append(events, event)
+			}
+			pointer.x, pointer.y = e.X, e.Y
+			return append(events, g.up(button, now)...)
+		}
+	}
+	return nil
+}
+
+func (g *gestureRecognizer) down(button Button, x, y int32, now time.Time) []Event {
+	events := []Event{}
+	// Pan ends when a second finger is down
+	if len(g.pointers) == 1 && g.panning {
+		g.panning = false
+		events = append(events, g.panEvent(TypeUp))
+	}
+	g.pointers[button] = &gesturePointer{startX: x, startY: y, x: x, y: y}
+	switch len(g.pointers) {
+	case 1:
+		g.fingers, g.start, g.pressed, g.panning = 1, now, false, false
+		g.longPress = now.Add(g.thresholds.LongPressDuration)
+	case 2:
+		g.stopLongPress()
+		g.fingers = 2
+	}
+	return append(events, g.updatePair()...)
+}
+
+func (g *gestureRecognizer) move() []Event {
+	switch {
+	case g.fingers == 1:
+		pointer := g.single()
+		if pointer == nil || (!g.panning && g.moved(pointer) <= float64(g.thresholds.TapSlop)) {
+			return nil
+		}
+		g.stopLongPress()
+		if !g.panning {
+			g.panning = true
+			return []Event{g.panEvent(TypeDown)}
+		}
+		return []Event{g.panEvent(TypeMove)}
+	case g.pair[0] != nil:
+		events := []Event{}
+		scale, rotation := g.twoFingersChange()
+		if !g.pinching && float32(math.Abs(float64(scale-1))) > g.thresholds.PinchMinScale {
+			g.pinching = true
+			events = append(events, g.twoFingersEvent(GesturePinch, TypeDown, scale, rotation))
+		} else if g.pinching {
+			events = append(events, g.twoFingersEvent(GesturePinch, TypeMove, scale, rotation))
+		}
+		if !g.rotating && float32(math.Abs(float64(rotation))) > g.thresholds.RotateMinAngle {
+			g.rotating = true
+			events = append(events, g.twoFingersEvent(GestureRotate, TypeDown, scale, rotation))
+		} else if g.rotating {
+			events = append(events, g.twoFingersEvent(GestureRotate, TypeMove, scale, rotation))
+		}
+		return events
+	}
+	return nil
+}
+
+func (g *gestureRecognizer) up(button Button, now time.Time) []Event {
+	events := []Event{}
+	pointer := g.pointers[button]
+	if len(g.pointers) == 1 && g.fingers == 1 {
+		g.stopLongPress()
+		if g.panning {
+			events = append(events, g.panEvent(TypeUp))
+		}
+		duration := now.Sub(g.start)
+		moved := g.moved(pointer)
+		if !g.pressed && duration <= g.thresholds.TapMaxDuration && moved <= float64(g.thresholds.TapSlop) {
+			events = append(events, GestureEvent{Gesture: GestureTap, X: pointer.x, Y: pointer.y})
+			if !g.lastTap.IsZero() && now.Sub(g.lastTap) <= g.thresholds.DoubleTapDelay &&
+				math.Hypot(float64(pointer.x-g.lastTapX), float64(pointer.y-g.lastTapY)) <= float64(2*g.thresholds.TapSlop) {
+				events = append(events, GestureEvent{Gesture: GestureDoubleTap, X: pointer.x, Y: pointer.y})
+				g.lastTap = time.Time{}
+			} else {
+				g.lastTap, g.lastTapX, g.lastTapY = now, pointer.x, pointer.y
+			}
+		} else if velocity := float32(moved / math.Max(duration.Seconds(), 0.001)); moved >= float64(g.thresholds.SwipeMinDistance) &&
+			velocity >= g.thresholds.SwipeMinVelocity {
+			events = append(events, GestureEvent{
+				Gesture:  GestureSwipe,
+				X:        pointer.x,
+				Y:        pointer.y,
+				DX:       pointer.x - pointer.startX,
+				DY:       pointer.y - pointer.startY,
+				Velocity: velocity,
+			})
+		}
+	}
+	delete(g.pointers, button)
+	events = append(events, g.updatePair()...)
+	if len(g.pointers) == 0 {
+		g.stopLongPress()
+		g.fingers, g.panning = 0, false
+	}
+	return events
+}
+
+// poll publishes the long press once its duration is elapsed, it is called at each
+// tick as no event is received while the finger does not move
+func (g *gestureRecognizer) poll(now time.Time) {
+	g.mutex.Lock()
+	event, ok := g.longPressed(now)
+	g.mutex.Unlock()
+	if ok {
+		g.publish(event)
+	}
+}
+
+// longPressed returns the long press of the sequence if its duration is elapsed at
+// now and the finger did not move
+func (g *gestureRecognizer) longPressed(now time.Time) (Event, bool) {
+	pointer := g.single()
+	if g.longPress.IsZero() || now.Before(g.longPress) || g.fingers != 1 || pointer == nil {
+		return nil, false
+	}
+	g.stopLongPress()
+	g.pressed = true
+	return GestureEvent{Gesture: GestureLongPress, X: pointer.x, Y: pointer.y}, true
+}
+
+func (g *gestureRecognizer) stopLongPress() {
+	g.longPress = time.Time{}
+}
+
+// single returns the pointer of a single finger sequence, nil if several fingers are down
+func (g *gestureRecognizer) single() *gesturePointer {
+	if len(g.pointers) != 1 {
+		return nil
+	}
+	for _, pointer := range g.pointers {
+		return pointer
+	}
+	return nil
+}
+
+// moved returns the distance between the start and current positions of pointer
+func (g *gestureRecognizer) moved(pointer *gesturePointer) float64 {
+	return math.Hypot(float64(pointer.x-pointer.startX), float64(pointer.y-pointer.startY))
+}
+
+func (g *gestureRecognizer) panEvent(eventType Type) Event {
+	pointer := g.single()
+	return GestureEvent{
+		Gesture: GesturePan,
+		Type:    eventType,
+		X:       pointer.x,
+		Y:       pointer.y,
+		DX:      pointer.x - pointer.startX,
+		DY:      pointer.y - pointer.startY,
+	}
+}
+
+// updatePair follows the two first pointers down, pinch and rotation end and their
+// beginning is set again when a finger down or up changes them
+func (g *gestureRecognizer) updatePair() []Event {
+	var pair [2]*gesturePointer
+	if len(g.pointers) >= 2 {
+		pair[0], pair[1] = g.firstPointers()
+	}
+	if pair == g.pair {
+		return nil
+	}
+	events := []Event{}
+	if g.pair[0] != nil {
+		scale, rotation := g.twoFingersChange()
+		if g.pinching {
+			events = append(events, g.twoFingersEvent(GesturePinch, TypeUp, scale, rotation))
+		}
+		if g.rotating {
+			events = append(events, g.twoFingersEvent(GestureRotate, TypeUp, scale, rotation))
+		}
+	}
+	g.pair, g.pinching, g.rotating = pair, false, false
+	if pair[0] != nil {
+		g.distance0, g.angle0 = g.twoFingers()
+	}
+	return events
+}
+
+// twoFingers returns the distance and the angle between the pointers of the pair
+func (g *gestureRecognizer) twoFingers() (float64, float64) {
+	first, second := g.pair[0], g.pair[1]
+	dx, dy := float64(second.x-first.x), float64(second.y-first.y)
+	return math.Hypot(dx, dy), math.Atan2(dy, dx)
+}
+
+// twoFingersChange returns the scale and the rotation of the pair since its beginning
+func (g *gestureRecognizer) twoFingersChange() (float32, float32) {
+	distance, angle := g.twoFingers()
+	scale := float32(1)
+	if g.distance0 > 0 {
+		scale = float32(distance / g.distance0)
+	}
+	return scale, float32(math.Remainder(angle-g.angle0, 2*math.Pi))
+}
+
+func (g *gestureRecognizer) twoFingersEvent(gesture GestureType, eventType Type, scale float32, angle float32) Event {
+	first, second := g.pair[0], g.pair[1]
+	return GestureEvent{
+		Gesture: gesture,
+		Type:    eventType,
+		X:       (first.x + second.x) / 2,
+		Y:       (first.y + second.y) / 2,
+		Scale:   scale,
+		Angle:   angle,
+	}
+}
+
+// firstPointers returns two pointers ordered by Button to keep angles stable
+func (g *gestureRecognizer) firstPointers() (*gesturePointer, *gesturePointer) {
+	pointers := make([]*gesturePointer, 0, 2)
+	for _, button := range []Button{TouchFirst, TouchSecond, TouchThird} {
+		if pointer, found := g.pointers[button]; found && len(pointers) < 2 {
+			pointers = append(pointers, pointer)
+		}
+	}
+	return pointers[0], pointers[1]
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	fmt "fmt"
+	reflect "reflect"
+	testing "testing"
+	time "time"
+)
+
+// gestureStep is an event given to the recognizer at a time in milliseconds, a step
+// without event polls the long press
+type gestureStep struct {
+	at    int
+	event *MouseEvent
+}
+
+func gestureTouch(at int, button Button, eventType Type, x, y int32) gestureStep {
+	return gestureStep{at: at, event: &MouseEvent{Button: button, Type: eventType, X: x, Y: y}}
+}
+
+func gestureTick(at int) gestureStep {
+	return gestureStep{at: at}
+}
+
+func TestGestureRecognizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []gestureStep
+		want  []string
+	}{
+		{"tap", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(100, TouchFirst, TypeUp, 12, 11),
+		}, []string{"GestureTap"}},
+		{"mouse tap", []gestureStep{
+			gestureTouch(0, ButtonLeft, TypeDown, 10, 10),
+			gestureTouch(100, ButtonLeft, TypeUp, 10, 10),
+		}, []string{"GestureTap"}},
+		{"right button ignored", []gestureStep{
+			gestureTouch(0, ButtonRight, TypeDown, 10, 10),
+			gestureTouch(100, ButtonRight, TypeUp, 10, 10),
+		}, nil},
+		{"slow tap", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(400, TouchFirst, TypeUp, 10, 10),
+		}, nil},
+		{"double tap", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(100, TouchFirst, TypeUp, 10, 10),
+			gestureTouch(200, TouchFirst, TypeDown, 12, 12),
+			gestureTouch(300, TouchFirst, TypeUp, 12, 12),
+		}, []string{"GestureTap", "GestureTap", "GestureDoubleTap"}},
+		{"late second tap", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(100, TouchFirst, TypeUp, 10, 10),
+			gestureTouch(500, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(600, TouchFirst, TypeUp, 10, 10),
+		}, []string{"GestureTap", "GestureTap"}},
+		{"long press on tick", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTick(400),
+			gestureTick(600),
+			gestureTick(700),
+			gestureTouch(800, TouchFirst, TypeUp, 10, 10),
+		}, []string{"GestureLongPress"}},
+		{"long press on release", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(600, TouchFirst, TypeUp, 10, 10),
+		}, []string{"GestureLongPress"}},
+		{"long press cancelled by motion", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 10, 10),
+			gestureTouch(100, TouchFirst, TypeMove, 40, 10),
+			gestureTick(600),
+			gestureTouch(1000, TouchFirst, TypeUp, 40, 10),
+		}, []string{"GesturePan:TypeDown", "GesturePan:TypeUp"}},
+		{"swipe", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(50, TouchFirst, TypeMove, 100, 0),
+			gestureTouch(100, TouchFirst, TypeUp, 200, 0),
+		}, []string{"GesturePan:TypeDown", "GesturePan:TypeUp", "GestureSwipe"}},
+		{"pan", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(500, TouchFirst, TypeMove, 20, 0),
+			gestureTouch(1000, TouchFirst, TypeMove, 40, 0),
+			gestureTouch(2000, TouchFirst, TypeUp, 60, 0),
+		}, []string{"GesturePan:TypeDown", "GesturePan:TypeMove", "GesturePan:TypeUp"}},
+		{"pinch", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(10, TouchSecond, TypeDown, 100, 0),
+			gestureTouch(20, TouchSecond, TypeMove, 200, 0),
+			gestureTouch(30, TouchSecond, TypeMove, 300, 0),
+			gestureTouch(40, TouchSecond, TypeUp, 300, 0),
+			gestureTouch(50, TouchFirst, TypeUp, 0, 0),
+		}, []string{"GesturePinch:TypeDown", "GesturePinch:TypeMove", "GesturePinch:TypeUp"}},
+		{"rotate", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(10, TouchSecond, TypeDown, 100, 0),
+			gestureTouch(20, TouchSecond, TypeMove, 0, 100),
+			gestureTouch(30, TouchFirst, TypeUp, 0, 0),
+			gestureTouch(40, TouchSecond, TypeUp, 0, 100),
+		}, []string{"GestureRotate:TypeDown", "GestureRotate:TypeUp"}},
+		{"third finger up keeps pair", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(10, TouchSecond, TypeDown, 100, 0),
+			gestureTouch(20, TouchThird, TypeDown, 500, 500),
+			gestureTouch(30, TouchThird, TypeUp, 500, 500),
+			gestureTouch(40, TouchSecond, TypeMove, 101, 0),
+		}, nil},
+		{"first finger up changes pair", []gestureStep{
+			gestureTouch(0, TouchFirst, TypeDown, 0, 0),
+			gestureTouch(10, TouchSecond, TypeDown, 100, 0),
+			gestureTouch(20, TouchThird, TypeDown, 100, 300),
+			gestureTouch(30, TouchFirst, TypeUp, 0, 0),
+			gestureTouch(40, TouchThird, TypeMove, 100, 301),
+		}, nil},
+		{"first finger down changes pair", []gestureStep{
+			gestureTouch(0, TouchSecond, TypeDown, 0, 0),
+			gestureTouch(10, TouchThird, TypeDown, 100, 0),
+			gestureTouch(20, TouchThird, TypeMove, 200, 0),
+			gestureTouch(30, TouchFirst, TypeDown, 0, 300),
+			gestureTouch(40, TouchFirst, TypeMove, 0, 301),
+		}, []string{"GesturePinch:TypeDown", "GesturePinch:TypeUp"}},
+	}
+	for _, test := range tests {
+		var got []string
+		g := newGestureRecognizer(func(event Event) {
+			got = append(got, gestureName(event))
+		})
+		start := time.Now()
+		for _, step := range test.steps {
+			now := start.Add(time.Duration(step.at) * time.Millisecond)
+			if step.event == nil {
+				g.poll(now)
+				continue
+			}
+			for _, event := range g.handle(*step.event, now) {
+				got = append(got, gestureName(event))
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: recognized %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// gestureName returns the gesture of event followed by its type if it is continuous
+func gestureName(event Event) string {
+	e := event.(GestureEvent)
+	if e.Type == TypeNone {
+		return e.Gesture.String()
+	}
+	return fmt.Sprintf("%s:%s", e.Gesture, e.Type)
+}
@@ -1,8 +1,9 @@
 module github.com/thommil/tge
 
-go 1.12
+go 1.16
 
 require (
-	github.com/thommil/tge-mobile v0.0.0-20190304155026-a0779a310b28 // indirect
+	github.com/thommil/tge-mobile v0.0.0-20190304155026-a0779a310b28
 	github.com/veandco/go-sdl2 v0.3.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,290 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	sort "sort"
+	sync "sync"
+)
+
+// Touch buttons, used to separate touches from mouse buttons
+const touchButtons = TouchFirst | TouchSecond | TouchThird
+
+// -------------------------------------------------------------------- //
+// Input snapshot
+// -------------------------------------------------------------------- //
+
+// Input is the state of input devices taken before each OnTick(), it is not modified
+// afterwards and can be read from any goroutine. Pressed and released states cover
+// all the events received since the previous OnTick(), a key pressed and released
+// between two ticks is then both pressed and released but not down.
+type Input struct {
+	keys            map[KeyCode]bool
+	pressedKeys     map[KeyCode]bool
+	releasedKeys    map[KeyCode]bool
+	buttons         map[Button]bool
+	pressedButtons  map[Button]bool
+	releasedButtons map[Button]bool
+	mouseX, mouseY  int32
+	touches         []Touch
+	gamepadButtons  map[GamepadButton]bool
+	gamepadAxes     map[GamepadAxis]float32
+}
+
+// Touch is an active touch of the screen
+type Touch struct {
+	Button Button
+	X, Y   int32
+}
+
+func (core *runtimeCore) GetInput() *Input {
+	return core.input.getSnapshot()
+}
+
+// releaseInputs publishes the release of the keys and buttons down when the App loses
+// the focus, as their release events are then received by another window
+func (core *runtimeCore) releaseInputs() {
+	for _, event := range core.input.releaseEvents() {
+		core.Publish(event)
+	}
+}
+
+// IsKeyDown indicates if key is down
+func (input *Input) IsKeyDown(key KeyCode) bool {
+	return input.keys[key]
+}
+
+// IsKeyPressed indicates if key has been pressed since previous OnTick()
+func (input *Input) IsKeyPressed(key KeyCode) bool {
+	return input.pressedKeys[key]
+}
+
+// IsKeyReleased indicates if key has been released since previous OnTick()
+func (input *Input) IsKeyReleased(key KeyCode) bool {
+	return input.releasedKeys[key]
+}
+
+// Keys returns the keys down ordered by KeyCode
+func (input *Input) Keys() []KeyCode {
+	keys := make([]KeyCode, 0, len(input.keys))
+	for key := range input.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// IsButtonDown indicates if a mouse button or a touch is down
+func (input *Input) IsButtonDown(button Button) bool {
+	return input.buttons[button]
+}
+
+// IsButtonPressed indicates if a mouse button or a touch has been pressed since previous OnTick()
+func (input *Input) IsButtonPressed(button Button) bool {
+	return input.pressedButtons[button]
+}
+
+// IsButtonReleased indicates if a mouse button or a touch has been released since previous OnTick()
+func (input *Input) IsButtonReleased(button Button) bool {
+	return input.releasedButtons[button]
+}
+
+// MousePosition returns the last known position of the mouse
+func (input *Input) MousePosition() (x, y int32) {
+	return input.mouseX, input.mouseY
+}
+
+// Touches returns the active touches ordered by Button
+func (input *Input) Touches() []Touch {
+	return append([]Touch(nil), input.touches...)
+}
+
+// isGamepadButtonDown indicates if button is down on any gamepad
+func (input *Input) isGamepadButtonDown(button GamepadButton) bool {
+	return input.gamepadButtons[button]
+}
+
+// gamepadAxis returns the value of axis with the largest amplitude across gamepads
+func (input *Input) gamepadAxis(axis GamepadAxis) float32 {
+	return input.gamepadAxes[axis]
+}
+
+// -------------------------------------------------------------------- //
+// Input state
+// -------------------------------------------------------------------- //
+
+// inputState tracks the state of input devices from the events published by the
+// Runtime, it is updated before listeners are called
+type inputState struct {
+	mutex           sync.RWMutex
+	keys            map[KeyCode]bool
+	pressedKeys     map[KeyCode]bool
+	releasedKeys    map[KeyCode]bool
+	buttons         map[Button]bool
+	pressedButtons  map[Button]bool
+	releasedButtons map[Button]bool
+	mouseX, mouseY  int32
+	touches         map[Button]Touch
+	gamepadButtons  map[int32]map[GamepadButton]bool
+	gamepadAxes     map[int32]map[GamepadAxis]float32
+	current         *Input
+}
+
+func newInputState() *inputState {
+	state := &inputState{
+		keys:           make(map[KeyCode]bool),
+		buttons:        make(map[Button]bool),
+		touches:        make(map[Button]Touch),
+		gamepadButtons: make(map[int32]map[GamepadButton]bool),
+		gamepadAxes:    make(map[int32]map[GamepadAxis]float32),
+	}
+	state.resetChanges()
+	state.current = state.copy()
+	return state
+}
+
+// snapshot replaces the current Input by the live state and starts collecting
+// pressed and released changes for the next tick
+func (state *inputState) snapshot() {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.current = state.copy()
+	state.resetChanges()
+}
+
+// getSnapshot returns the Input taken before the current tick
+func (state *inputState) getSnapshot() *Input {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	return state.current
+}
+
+// copy returns the live state as Input, the mutex must be held
+func (state *inputState) copy() *Input {
+	input := &Input{
+		keys:            make(map[KeyCode]bool, len(state.keys)),
+		pressedKeys:     state.pressedKeys,
+		releasedKeys:    state.releasedKeys,
+		buttons:         make(map[Button]bool, len(state.buttons)),
+		pressedButtons:  state.pressedButtons,
+		releasedButtons: state.releasedButtons,
+		mouseX:          state.mouseX,
+		mouseY:          state.mouseY,
+		touches:         make([]Touch, 0, len(state.touches)),
+		gamepadButtons:  make(map[GamepadButton]bool),
+		gamepadAxes:     make(map[GamepadAxis]float32),
+	}
+	for key := range state.keys {
+		input.keys[key] = true
+	}
+	for button := range state.buttons {
+		input.buttons[button] = true
+	}
+	for _, buttons := range state.gamepadButtons {
+		for button := range buttons {
+			input.gamepadButtons[button] = true
+		}
+	}
+	for _, axes := range state.gamepadAxes {
+		for axis, value := range axes {
+			if current := input.gamepadAxes[axis]; value*value > current*current {
+				input.gamepadAxes[axis] = value
+			}
+		}
+	}
+	for _, touch := range state.touches {
+		input.touches = append(input.touches, touch)
+	}
+	sort.Slice(input.touches, func(i, j int) bool { return input.touches[i].Button < input.touches[j].Button })
+	return input
+}
+
+// resetChanges starts new pressed and released sets, the previous ones belong to the snapshot
+func (state *inputState) resetChanges() {
+	state.pressedKeys = make(map[KeyCode]bool)
+	state.releasedKeys = make(map[KeyCode]bool)
+	state.pressedButtons = make(map[Button]bool)
+	state.releasedButtons = make(map[Button]bool)
+}
+
+// update applies an input event to the state, other events are ignored
+func (state *inputState) update(event Event) {
+	switch e := event.(type) {
+	case KeyEvent:
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+		switch e.Type {
+		case TypeDown:
+			if !state.keys[e.Key] {
+				state.pressedKeys[e.Key] = true
+			}
+			state.keys[e.Key] = true
+		case TypeUp:
+			if state.keys[e.Key] {
+				state.releasedKeys[e.Key] = true
+			}
+			delete(state.keys, e.Key)
+		}
+	case MouseEvent:
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+		switch e.Type {
+		case TypeDown:
+			if !state.buttons[e.Button] {
+				state.pressedButtons[e.Button] = true
+			}
+			state.buttons[e.Button] = true
+		case TypeUp:
+			if state.buttons[e.Button] {
+				state.releasedButtons[e.Button] = true
+			}
+			delete(state.buttons, e.Button)
+		}
+		if e.Button&touchButtons != 0 {
+			if e.Type == TypeUp {
+				delete(state.touches, e.Button)
+			} else {
+				state.touches[e.Button] = Touch{Button: e.Button, X: e.X, Y: e.Y}
+			}
+		} else {
+			state.mouseX, state.mouseY = e.X, e.Y
+		}
+	case GamepadEvent:
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+		switch e.Type {
+		case TypeDown, TypeUp:
+			if state.gamepadButtons[e.Gamepad] == nil {
+				state.gamepadButtons[e.Gamepad] = make(map[GamepadButton]bool)
+			}
+			if e.Type == TypeDown {
+				state.gamepadButtons[e.Gamepad][e.Button] = true
+			} else {
+				delete(state.gamepadButtons[e.Gamepad], e.Button)
+			}
+		case TypeMove:
+			if state.gamepadAxes[e.Gamepad] == nil {
+				state.gamepadAxes[e.Gamepad] = make(map[GamepadAxis]float32)
+			}
+			state.gamepadAxes[e.Gamepad][e.Axis] = e.Value
+		}
+	}
+}
+
+// releaseEvents returns the events releasing the keys and buttons down
+func (state *inputState) releaseEvents() []Event {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	events := make([]Event, 0, len(state.keys)+len(state.buttons))
+	for key := range state.keys {
+		events = append(events, KeyEvent{Key: key, Type: TypeUp})
+	}
+	for button := range state.buttons {
+		x, y := state.mouseX, state.mouseY
+		if touch, found := state.touches[button]; found {
+			x, y = touch.X, touch.Y
+		}
+		events = append(events, MouseEvent{X: x, Y: y, Button: button, Type: TypeUp})
+	}
+	return events
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	reflect "reflect"
+	testing "testing"
+)
+
+func TestInputStateKeys(t *testing.T) {
+	down := func(key KeyCode) Event { return KeyEvent{Key: key, Type: TypeDown} }
+	up := func(key KeyCode) Event { return KeyEvent{Key: key, Type: TypeUp} }
+	tests := []struct {
+		name     string
+		previous []Event
+		events   []Event
+		down     bool
+		pressed  bool
+		released bool
+	}{
+		{"none", nil, nil, false, false, false},
+		{"pressed", nil, []Event{down(KeyCodeA)}, true, true, false},
+		{"held", []Event{down(KeyCodeA)}, nil, true, false, false},
+		{"repeated", []Event{down(KeyCodeA)}, []Event{down(KeyCodeA)}, true, false, false},
+		{"released", []Event{down(KeyCodeA)}, []Event{up(KeyCodeA)}, false, false, true},
+		{"tapped", nil, []Event{down(KeyCodeA), up(KeyCodeA)}, false, true, true},
+		{"released and pressed", []Event{down(KeyCodeA)}, []Event{up(KeyCodeA), down(KeyCodeA)}, true, true, true},
+		{"released without press", nil, []Event{up(KeyCodeA)}, false, false, false},
+		{"other key", nil, []Event{down(KeyCodeB)}, false, false, false},
+	}
+	for _, test := range tests {
+		state := newInputState()
+		for _, event := range test.previous {
+			state.update(event)
+		}
+		state.snapshot()
+		for _, event := range test.events {
+			state.update(event)
+		}
+		state.snapshot()
+		input := state.getSnapshot()
+		if input.IsKeyDown(KeyCodeA) != test.down || input.IsKeyPressed(KeyCodeA) != test.pressed || input.IsKeyReleased(KeyCodeA) != test.released {
+			t.Errorf("%s: down %v pressed %v released %v, want %v %v %v", test.name,
+				input.IsKeyDown(KeyCodeA), input.IsKeyPressed(KeyCodeA), input.IsKeyReleased(KeyCodeA),
+				test.down, test.pressed, test.released)
+		}
+	}
+}
+
+func TestInputStateSnapshot(t *testing.T) {
+	state := newInputState()
+	state.update(MouseEvent{Button: ButtonLeft, Type: TypeDown, X: 10, Y: 20})
+	state.update(MouseEvent{Button: TouchSecond, Type: TypeDown, X: 3, Y: 4})
+	state.update(MouseEvent{Button: TouchFirst, Type: TypeDown, X: 1, Y: 2})
+	state.update(KeyEvent{Key: KeyCodeB, Type: TypeDown})
+	state.update(KeyEvent{Key: KeyCodeA, Type: TypeDown})
+	state.update(GamepadEvent{Gamepad: 0, Axis: GamepadAxisLeftX, Value: 0.3, Type: TypeMove})
+	state.update(GamepadEvent{Gamepad: 1, Axis: GamepadAxisLeftX, Value: -0.8, Type: TypeMove})
+	state.update(GamepadEvent{Gamepad: 1, Button: GamepadButtonA, Type: TypeDown})
+
+	before := state.getSnapshot()
+	if before.IsButtonDown(ButtonLeft) || len(before.Keys()) != 0 {
+		t.Error("events should not change the Input before snapshot()")
+	}
+	state.snapshot()
+	input := state.getSnapshot()
+
+	if x, y := input.MousePosition(); x != 10 || y != 20 {
+		t.Errorf("MousePosition() = %d, %d, want 10, 20", x, y)
+	}
+	wantTouches := []Touch{{Button: TouchFirst, X: 1, Y: 2}, {Button: TouchSecond, X: 3, Y: 4}}
+	if touches := input.Touches(); !reflect.DeepEqual(touches, wantTouches) {
+		t.Errorf("Touches() = %v, want %v", touches, wantTouches)
+	}
+	if keys := input.Keys(); !reflect.DeepEqual(keys, []KeyCode{KeyCodeA, KeyCodeB}) {
+		t.Errorf("Keys() = %v, want [A B]", keys)
+	}
+	if value := input.gamepadAxis(GamepadAxisLeftX); value != -0.8 {
+		t.Errorf("gamepadAxis(LeftX) = %v, want -0.8", value)
+	}
+	if !input.isGamepadButtonDown(GamepadButtonA) || input.isGamepadButtonDown(GamepadButtonB) {
+		t.Error("isGamepadButtonDown() should only report A")
+	}
+	if !input.IsButtonPressed(TouchFirst) || !input.IsButtonDown(ButtonLeft) {
+		t.Error("buttons should be down and pressed")
+	}
+
+	// Snapshots are not modified by later events
+	state.update(MouseEvent{Button: TouchFirst, Type: TypeUp, X: 1, Y: 2})
+	state.snapshot()
+	if !input.IsButtonDown(TouchFirst) || len(input.Touches()) != 2 {
+		t.Error("a snapshot should not change after next snapshot()")
+	}
+	if next := state.getSnapshot(); next.IsButtonDown(TouchFirst) || !next.IsButtonReleased(TouchFirst) || next.IsButtonPressed(TouchSecond) {
+		t.Error("the touch should be released in the next snapshot")
+	}
+}
+
+func TestInputStateReleaseEvents(t *testing.T) {
+	state := newInputState()
+	state.update(KeyEvent{Key: KeyCodeA, Type: TypeDown})
+	state.update(MouseEvent{Button: ButtonRight, Type: TypeDown, X: 5, Y: 6})
+	state.update(MouseEvent{Button: TouchFirst, Type: TypeDown, X: 7, Y: 8})
+	events := state.releaseEvents()
+	if len(events) != 3 {
+		t.Fatalf("releaseEvents() = %v, want 3 events", events)
+	}
+	for _, event := range events {
+		switch e := event.(type) {
+		case KeyEvent:
+			if e.Key != KeyCodeA || e.Type != TypeUp {
+				t.Errorf("unexpected release %+v", e)
+			}
+		case MouseEvent:
+			if e.Type != TypeUp || (e.Button == ButtonRight && (e.X != 5 || e.Y != 6)) || (e.Button == TouchFirst && (e.X != 7 || e.Y != 8)) {
+				t.Errorf("unexpected release %+v", e)
+			}
+		}
+		state.update(event)
+	}
+	state.snapshot()
+	input := state.getSnapshot()
+	if len(input.Keys()) != 0 || len(input.Touches()) != 0 || input.IsButtonDown(ButtonRight) {
+		t.Error("inputs should be released")
+	}
+	if len(state.releaseEvents()) != 0 {
+		t.Error("releaseEvents() should be empty once released")
+	}
+}
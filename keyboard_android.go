@@ -0,0 +1,200 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android
+// +build android
+
+package tge
+
+/*
+#include <jni.h>
+#include <stdint.h>
+
+// Results of setKeyboardVisible
+enum {
+	KEYBOARD_OK,
+	KEYBOARD_EXCEPTION,
+	KEYBOARD_UNAVAILABLE
+};
+
+// Number of local references used by updateKeyboard
+#define KEYBOARD_LOCAL_REFS 16
+
+// keyboardException logs and clears a pending Java exception
+static int keyboardException(JNIEnv* env) {
+	if (!(*env)->ExceptionCheck(env)) {
+		return 0;
+	}
+	(*env)->ExceptionDescribe(env);
+	(*env)->ExceptionClear(env);
+	return 1;
+}
+
+// updateKeyboard shows or hides the soft keyboard using the InputMethodManager of the
+// activity, local references are released by the caller
+static int updateKeyboard(JNIEnv* env, jobject activity, int visible) {
+	jclass contextClass = (*env)->FindClass(env, "android/content/Context");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jfieldID serviceField = (*env)->GetStaticFieldID(env, contextClass, "INPUT_METHOD_SERVICE", "Ljava/lang/String;");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jobject serviceName = (*env)->GetStaticObjectField(env, contextClass, serviceField);
+	jmethodID getSystemService = (*env)->GetMethodID(env, contextClass, "getSystemService", "(Ljava/lang/String;)Ljava/lang/Object;");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jobject inputMethodManager = (*env)->CallObjectMethod(env, activity, getSystemService, serviceName);
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	if (inputMethodManager == NULL) {
+		return KEYBOARD_UNAVAILABLE;
+	}
+
+	jclass activityClass = (*env)->FindClass(env, "android/app/Activity");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jmethodID getWindow = (*env)->GetMethodID(env, activityClass, "getWindow", "()Landroid/view/Window;");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jobject window = (*env)->CallObjectMethod(env, activity, getWindow);
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	if (window == NULL) {
+		return KEYBOARD_UNAVAILABLE;
+	}
+	jclass windowClass = (*env)->FindClass(env, "android/view/Window");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jmethodID getDecorView = (*env)->GetMethodID(env, windowClass, "getDecorView", "()Landroid/view/View;");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	jobject decorView = (*env)->CallObjectMethod(env, window, getDecorView);
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	if (decorView == NULL) {
+		return KEYBOARD_UNAVAILABLE;
+	}
+
+	jclass inputMethodManagerClass = (*env)->FindClass(env, "android/view/inputmethod/InputMethodManager");
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	if (visible) {
+		// The keyboard is only shown for the focused view (content view of the NativeActivity),
+		// SHOW_FORCED keeps it open as this view is not a text editor
+		jmethodID getCurrentFocus = (*env)->GetMethodID(env, activityClass, "getCurrentFocus", "()Landroid/view/View;");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jobject focusedView = (*env)->CallObjectMethod(env, activity, getCurrentFocus);
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		if (focusedView == NULL) {
+			focusedView = decorView;
+		}
+		jfieldID showForcedField = (*env)->GetStaticFieldID(env, inputMethodManagerClass, "SHOW_FORCED", "I");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jint showForced = (*env)->GetStaticIntField(env, inputMethodManagerClass, showForcedField);
+		jmethodID showSoftInput = (*env)->GetMethodID(env, inputMethodManagerClass, "showSoftInput", "(Landroid/view/View;I)Z");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jboolean shown = (*env)->CallBooleanMethod(env, inputMethodManager, showSoftInput, focusedView, showForced);
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		if (!shown) {
+			// No view is served by the input method, the keyboard is then toggled
+			jmethodID toggleSoftInput = (*env)->GetMethodID(env, inputMethodManagerClass, "toggleSoftInput", "(II)V");
+			if (keyboardException(env)) {
+				return KEYBOARD_EXCEPTION;
+			}
+			(*env)->CallVoidMethod(env, inputMethodManager, toggleSoftInput, showForced, 0);
+		}
+	} else {
+		jclass viewClass = (*env)->FindClass(env, "android/view/View");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jmethodID getWindowToken = (*env)->GetMethodID(env, viewClass, "getWindowToken", "()Landroid/os/IBinder;");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jobject windowToken = (*env)->CallObjectMethod(env, decorView, getWindowToken);
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		jmethodID hideSoftInputFromWindow = (*env)->GetMethodID(env, inputMethodManagerClass, "hideSoftInputFromWindow", "(Landroid/os/IBinder;I)Z");
+		if (keyboardException(env)) {
+			return KEYBOARD_EXCEPTION;
+		}
+		(*env)->CallBooleanMethod(env, inputMethodManager, hideSoftInputFromWindow, windowToken, 0);
+	}
+	if (keyboardException(env)) {
+		return KEYBOARD_EXCEPTION;
+	}
+	return KEYBOARD_OK;
+}
+
+// setKeyboardVisible calls updateKeyboard in a local frame, as threads of RunOnJVM do not
+// return to Java which would release the local references
+static int setKeyboardVisible(uintptr_t jniEnv, uintptr_t ctx, int visible) {
+	JNIEnv* env = (JNIEnv*)jniEnv;
+	if ((*env)->PushLocalFrame(env, KEYBOARD_LOCAL_REFS) < 0) {
+		keyboardException(env);
+		return KEYBOARD_EXCEPTION;
+	}
+	int result = updateKeyboard(env, (jobject)ctx, visible);
+	(*env)->PopLocalFrame(env, NULL);
+	return result;
+}
+*/
+import "C"
+
+import (
+	errors "errors"
+
+	mobile "github.com/thommil/tge-mobile/app"
+)
+
+// showKeyboard shows the soft keyboard of the activity, as the NativeActivity has no
+// text editor the keyboard type is only a hint and the default layout is used. Keys
+// are received as key.Event by the Runtime, publish is then not used.
+func showKeyboard(keyboardType KeyboardType, publish func(event Event)) error {
+	return setKeyboardVisible(true)
+}
+
+// hideKeyboard hides the soft keyboard of the activity
+func hideKeyboard() error {
+	return setKeyboardVisible(false)
+}
+
+// setKeyboardVisible converts the result of the JNI calls to an error, Java exceptions
+// are written in the log of the device
+func setKeyboardVisible(visible bool) error {
+	return mobile.RunOnJVM(func(vm, jniEnv, ctx uintptr) error {
+		cVisible := C.int(0)
+		if visible {
+			cVisible = 1
+		}
+		switch C.setKeyboardVisible(C.uintptr_t(jniEnv), C.uintptr_t(ctx), cVisible) {
+		case C.KEYBOARD_EXCEPTION:
+			return errors.New("java exception raised by the input method manager")
+		case C.KEYBOARD_UNAVAILABLE:
+			return errors.New("input method manager not available")
+		}
+		return nil
+	})
+}
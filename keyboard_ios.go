@@ -0,0 +1,67 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build ios
+// +build ios
+
+package tge
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation -framework UIKit
+
+void tgeShowKeyboard(int keyboardType);
+void tgeHideKeyboard(void);
+*/
+import "C"
+
+import (
+	sync "sync"
+)
+
+// keyboardPublisher publishes the events of the soft keyboard, the GL view of gomobile
+// does not accept text input so the keyboard is attached to a hidden text field
+var keyboardPublisher struct {
+	mutex   sync.Mutex
+	publish func(event Event)
+}
+
+// showKeyboard shows the soft keyboard using a hidden text field, typed text and
+// editing keys are given to publish
+func showKeyboard(keyboardType KeyboardType, publish func(event Event)) error {
+	keyboardPublisher.mutex.Lock()
+	keyboardPublisher.publish = publish
+	keyboardPublisher.mutex.Unlock()
+	C.tgeShowKeyboard(C.int(keyboardType))
+	return nil
+}
+
+// hideKeyboard hides the soft keyboard
+func hideKeyboard() error {
+	C.tgeHideKeyboard()
+	return nil
+}
+
+// publishKeyboard gives an event of the soft keyboard to the Runtime
+func publishKeyboard(event Event) {
+	keyboardPublisher.mutex.Lock()
+	publish := keyboardPublisher.publish
+	keyboardPublisher.mutex.Unlock()
+	if publish != nil {
+		publish(event)
+	}
+}
+
+//export keyboardText
+func keyboardText(text *C.char) {
+	publishKeyboard(TextInputEvent{Text: C.GoString(text)})
+}
+
+//export keyboardKey
+func keyboardKey(enter C.int) {
+	keyCode := KeyCodeDeleteBackspace
+	if enter != 0 {
+		keyCode = KeyCodeReturnEnter
+	}
+	publishKeyboard(KeyEvent{Key: keyCode, Value: keyCode.String(), Type: TypeDown})
+	publishKeyboard(KeyEvent{Key: keyCode, Value: keyCode.String(), Type: TypeUp})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build ios
+// +build ios
+
+#include "_cgo_export.h"
+
+#import <UIKit/UIKit.h>
+
+// TGEKeyboardField is a hidden text field receiving the soft keyboard input, it is kept
+// empty and forwards typed text and editing keys to the Runtime
+@interface TGEKeyboardField : UITextField<UITextFieldDelegate>
+@end
+
+@implementation TGEKeyboardField
+
+- (BOOL)textField:(UITextField *)textField shouldChangeCharactersInRange:(NSRange)range replacementString:(NSString *)string {
+	if (string.length > 0) {
+		keyboardText((char*)string.UTF8String);
+	}
+	return NO;
+}
+
+- (BOOL)textFieldShouldReturn:(UITextField *)textField {
+	keyboardKey(1);
+	return NO;
+}
+
+// deleteBackward is called even if the field is empty
+- (void)deleteBackward {
+	keyboardKey(0);
+}
+
+@end
+
+static TGEKeyboardField* keyboardField = nil;
+
+// tgeShowKeyboard creates the field in the view of the root controller at first call
+// and gives it the focus, keyboardType follows the values of KeyboardType
+void tgeShowKeyboard(int keyboardType) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		if (keyboardField == nil) {
+			UIView* view = [UIApplication sharedApplication].keyWindow.rootViewController.view;
+			if (view == nil) {
+				return;
+			}
+			keyboardField = [[TGEKeyboardField alloc] initWithFrame:CGRectZero];
+			keyboardField.delegate = keyboardField;
+			keyboardField.autocorrectionType = UITextAutocorrectionTypeNo;
+			keyboardField.autocapitalizationType = UITextAutocapitalizationTypeNone;
+			keyboardField.spellCheckingType = UITextSpellCheckingTypeNo;
+			keyboardField.textColor = [UIColor clearColor];
+			keyboardField.tintColor = [UIColor clearColor];
+			[view addSubview:keyboardField];
+		}
+		switch (keyboardType) {
+		case 1:
+			keyboardField.keyboardType = UIKeyboardTypeNumbersAndPunctuation;
+			break;
+		case 2:
+			keyboardField.keyboardType = UIKeyboardTypeEmailAddress;
+			break;
+		default:
+			keyboardField.keyboardType = UIKeyboardTypeDefault;
+		}
+		keyboardField.secureTextEntry = keyboardType == 3;
+		[keyboardField reloadInputViews];
+		[keyboardField becomeFirstResponder];
+	});
+}
+
+// tgeHideKeyboard removes the focus from the field
+void tgeHideKeyboard(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		[keyboardField resignFirstResponder];
+	});
+}
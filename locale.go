@@ -0,0 +1,207 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	fmt "fmt"
+	fs "io/fs"
+	math "math"
+	strings "strings"
+	sync "sync"
+)
+
+// LocalePlaceholder is replaced by the current locale in assets paths, for instance
+// "strings/{locale}.json" is resolved as "strings/fr-FR.json" then "strings/fr.json"
+// and finally with the fallback locale
+const LocalePlaceholder = "{locale}"
+
+// DensityPlaceholder is replaced by the pixel density in assets paths, for instance
+// "ui/title{density}.png" is resolved as "ui/title@2x.png" then "ui/title.png"
+const DensityPlaceholder = "{density}"
+
+// Highest pixel density variant looked up in assets
+const maxPixelDensity = 4
+
+// -------------------------------------------------------------------- //
+// Locale and density
+// -------------------------------------------------------------------- //
+
+// localeState holds the locale and the pixel density used to resolve assets variants
+type localeState struct {
+	mutex          sync.RWMutex
+	locale         string
+	fallbackLocale string
+	pixelDensity   float32
+	variants       map[string]string
+}
+
+func (core *runtimeCore) GetLocale() string {
+	core.localeState.mutex.RLock()
+	defer core.localeState.mutex.RUnlock()
+	return core.localeState.locale
+}
+
+func (core *runtimeCore) SetLocale(locale string) {
+	core.localeState.mutex.Lock()
+	core.localeState.locale = normalizeLocale(locale)
+	core.localeState.variants = make(map[string]string)
+	core.localeState.mutex.Unlock()
+	_logger.Info("Locale changed", "locale", core.GetLocale())
+}
+
+func (core *runtimeCore) GetPixelDensity() float32 {
+	core.localeState.mutex.RLock()
+	defer core.localeState.mutex.RUnlock()
+	return core.localeState.pixelDensity
+}
+
+// initLocale sets the locale from Settings or from the platform and the pixel density
+func (core *runtimeCore) initLocale(settings Settings, pixelDensity float32) {
+	locale := settings.Locale
+	if locale == "" {
+		locale = systemLocale()
+	}
+	core.localeState.locale = normalizeLocale(locale)
+	core.localeState.fallbackLocale = normalizeLocale(settings.FallbackLocale)
+	if core.localeState.locale == "" {
+		core.localeState.locale = core.localeState.fallbackLocale
+	}
+	core.setPixelDensity(pixelDensity)
+	_logger.Info("Locale initialized", "locale", core.localeState.locale, "density", pixelDensity)
+}
+
+// setPixelDensity updates the density when the display changes
+func (core *runtimeCore) setPixelDensity(pixelDensity float32) {
+	core.localeState.mutex.Lock()
+	defer core.localeState.mutex.Unlock()
+	if pixelDensity < 1 {
+		pixelDensity = 1
+	}
+	if pixelDensity != core.localeState.pixelDensity {
+		core.localeState.pixelDensity = pixelDensity
+		core.localeState.variants = make(map[string]string)
+	}
+}
+
+// clearVariants forgets resolved variants when the assets tree changes
+func (core *runtimeCore) clearVariants() {
+	core.localeState.mutex.Lock()
+	defer core.localeState.mutex.Unlock()
+	core.localeState.variants = make(map[string]string)
+}
+
+// normalizeLocale converts platform locales (fr_FR.UTF-8, fr-fr ...) to the
+// BCP 47 form fr-FR, C and POSIX locales are ignored
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.Trim(strings.Replace(strings.TrimSpace(locale), "_", "-", -1), "-")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	parts := strings.Split(locale, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// localeCandidates returns locale and its parents (fr-FR, fr) followed by fallback
+// and its parents if different
+func localeCandidates(locale string, fallback string) []string {
+	candidates := []string{}
+	for _, l := range []string{locale, fallback} {
+		for l != "" {
+			found := false
+			for _, c := range candidates {
+				found = found || c == l
+			}
+			if !found {
+				candidates = append(candidates, l)
+			}
+			if i := strings.LastIndex(l, "-"); i > 0 {
+				l = l[:i]
+			} else {
+				l = ""
+			}
+		}
+	}
+	return candidates
+}
+
+// -------------------------------------------------------------------- //
+// Variants resolution
+// -------------------------------------------------------------------- //
+
+// resolveAsset returns the best variant of an asset path for the current locale and
+// pixel density, results are memoized until the locale or the assets tree change.
+// Only paths with placeholders are resolved, others are returned as is to avoid
+// probing the assets tree (HTTP requests on browser).
+func (core *runtimeCore) resolveAsset(p string) (string, error) {
+	name := assetPath(p)
+	if !strings.Contains(name, LocalePlaceholder) && !strings.Contains(name, DensityPlaceholder) {
+		return name, nil
+	}
+	core.localeState.mutex.RLock()
+	resolved, found := core.localeState.variants[name]
+	locale, fallback := core.localeState.locale, core.localeState.fallbackLocale
+	density := core.localeState.pixelDensity
+	core.localeState.mutex.RUnlock()
+	if found {
+		return resolved, nil
+	}
+
+	resolved = ""
+	for _, candidate := range assetVariants(name, locale, fallback, density) {
+		if info, err := fs.Stat(core.getAssets(), candidate); err == nil && !info.IsDir() {
+			resolved = candidate
+			break
+		}
+	}
+	if resolved == "" {
+		return "", &fs.PathError{Op: "resolve", Path: p, Err: fs.ErrNotExist}
+	}
+
+	core.localeState.mutex.Lock()
+	defer core.localeState.mutex.Unlock()
+	if core.localeState.variants != nil {
+		core.localeState.variants[name] = resolved
+	}
+	return resolved, nil
+}
+
+// assetVariants lists the candidate paths of name by order of preference, locales
+// replace LocalePlaceholder and densities replace DensityPlaceholder:
+//
+//	ui/{locale}/title{density}.png : ui/fr-FR/title@2x.png, ui/fr-FR/title.png,
+//	                                 ui/fr/title@2x.png, ui/fr/title.png ...
+func assetVariants(name string, locale string, fallback string, density float32) []string {
+	densities := []string{""}
+	if strings.Contains(name, DensityPlaceholder) {
+		densities = []string{}
+		for d := int(math.Ceil(float64(density))); d > 1; d-- {
+			if d <= maxPixelDensity {
+				densities = append(densities, fmt.Sprintf("@%dx", d))
+			}
+		}
+		densities = append(densities, "")
+	}
+
+	locales := []string{""}
+	if strings.Contains(name, LocalePlaceholder) {
+		locales = localeCandidates(locale, fallback)
+	}
+
+	variants := []string{}
+	for _, l := range locales {
+		localized := strings.Replace(name, LocalePlaceholder, l, -1)
+		for _, d := range densities {
+			variants = append(variants, strings.Replace(localized, DensityPlaceholder, d, -1))
+		}
+	}
+	return variants
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android
+// +build android
+
+package tge
+
+import (
+	os "os"
+	exec "os/exec"
+	strings "strings"
+)
+
+// systemLocale returns the locale of the device from system properties
+func systemLocale() string {
+	for _, property := range []string{"persist.sys.locale", "ro.product.locale"} {
+		if output, err := exec.Command("getprop", property).Output(); err == nil {
+			if locale := strings.TrimSpace(string(output)); locale != "" {
+				return locale
+			}
+		}
+	}
+	// Before Android 6
+	if output, err := exec.Command("getprop", "persist.sys.language").Output(); err == nil {
+		if language := strings.TrimSpace(string(output)); language != "" {
+			country, _ := exec.Command("getprop", "persist.sys.country").Output()
+			return language + "-" + strings.TrimSpace(string(country))
+		}
+	}
+	return os.Getenv("LANG")
+}
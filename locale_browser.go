@@ -0,0 +1,26 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	js "syscall/js"
+)
+
+// systemLocale returns the preferred language of the browser
+func systemLocale() string {
+	if language := js.Global().Get("navigator").Get("language"); language.Type() == js.TypeString {
+		return language.String()
+	}
+	return ""
+}
+
+// devicePixelRatio returns the ratio between physical and CSS pixels
+func devicePixelRatio() float32 {
+	if ratio := js.Global().Get("devicePixelRatio"); ratio.Type() == js.TypeNumber {
+		return float32(ratio.Float())
+	}
+	return 1
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build darwin || ios
+// +build darwin ios
+
+package tge
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation
+
+#include <CoreFoundation/CoreFoundation.h>
+#include <stdlib.h>
+
+static int currentLocale(char *buffer, int size) {
+	CFLocaleRef locale = CFLocaleCopyCurrent();
+	CFStringRef identifier = CFLocaleGetIdentifier(locale);
+	int ok = CFStringGetCString(identifier, buffer, size, kCFStringEncodingUTF8);
+	CFRelease(locale);
+	return ok;
+}
+*/
+import "C"
+
+import (
+	unsafe "unsafe"
+)
+
+// Size of the buffer receiving the locale identifier
+const localeBufferSize = 64
+
+// systemLocale returns the locale of the current user from CoreFoundation
+func systemLocale() string {
+	buffer := (*C.char)(C.malloc(localeBufferSize))
+	defer C.free(unsafe.Pointer(buffer))
+	if C.currentLocale(buffer, localeBufferSize) == 0 {
+		return ""
+	}
+	return C.GoString(buffer)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !android && !darwin && !ios && !windows && !js
+// +build !android,!darwin,!ios,!windows,!js
+
+package tge
+
+import (
+	os "os"
+)
+
+// systemLocale returns the locale defined by POSIX environment variables
+func systemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
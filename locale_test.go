@@ -0,0 +1,111 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	errors "errors"
+	fs "io/fs"
+	reflect "reflect"
+	testing "testing"
+	fstest "testing/fstest"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", ""},
+		{"C", ""},
+		{"POSIX", ""},
+		{"C.UTF-8", ""},
+		{"fr", "fr"},
+		{"FR", "fr"},
+		{"fr_FR", "fr-FR"},
+		{"fr-fr", "fr-FR"},
+		{"fr_FR.UTF-8", "fr-FR"},
+		{"de_DE@euro", "de-DE"},
+		{" en-us ", "en-US"},
+		{"zh-Hant-TW", "zh-Hant-TW"},
+		{"en_", "en"},
+	}
+	for _, test := range tests {
+		if got := normalizeLocale(test.locale); got != test.want {
+			t.Errorf("normalizeLocale(%q) = %q, want %q", test.locale, got, test.want)
+		}
+	}
+}
+
+func TestAssetVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		locale   string
+		fallback string
+		density  float32
+		want     []string
+	}{
+		{"no placeholder", "ui/title.png", "fr-FR", "en", 2, []string{"ui/title.png"}},
+		{"locale", "strings/{locale}.json", "fr-FR", "en", 1,
+			[]string{"strings/fr-FR.json", "strings/fr.json", "strings/en.json"}},
+		{"same fallback", "strings/{locale}.json", "en-US", "en", 1,
+			[]string{"strings/en-US.json", "strings/en.json"}},
+		{"no locale", "strings/{locale}.json", "", "en", 1, []string{"strings/en.json"}},
+		{"density", "ui/title{density}.png", "fr", "en", 3,
+			[]string{"ui/title@3x.png", "ui/title@2x.png", "ui/title.png"}},
+		{"fractional density", "ui/title{density}.png", "fr", "en", 1.5,
+			[]string{"ui/title@2x.png", "ui/title.png"}},
+		{"maximum density", "ui/title{density}.png", "fr", "en", 6,
+			[]string{"ui/title@4x.png", "ui/title@3x.png", "ui/title@2x.png", "ui/title.png"}},
+		{"both", "ui/{locale}/title{density}.png", "fr-FR", "en", 2, []string{
+			"ui/fr-FR/title@2x.png", "ui/fr-FR/title.png",
+			"ui/fr/title@2x.png", "ui/fr/title.png",
+			"ui/en/title@2x.png", "ui/en/title.png",
+		}},
+	}
+	for _, test := range tests {
+		got := assetVariants(test.path, test.locale, test.fallback, test.density)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: assetVariants(%s) = %v, want %v", test.name, test.path, got, test.want)
+		}
+	}
+}
+
+func TestResolveAsset(t *testing.T) {
+	core := &runtimeCore{}
+	core.initAssets(fstest.MapFS{
+		"strings/fr.json":    &fstest.MapFile{},
+		"strings/en.json":    &fstest.MapFile{},
+		"ui/title@2x.png":    &fstest.MapFile{},
+		"ui/title.png":       &fstest.MapFile{},
+		"ui/dir@2x.png/file": &fstest.MapFile{},
+		"ui/dir.png":         &fstest.MapFile{},
+	}, Settings{FallbackLocale: "en"})
+	core.initLocale(Settings{Locale: "fr_CA", FallbackLocale: "en"}, 3)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"strings/{locale}.json", "strings/fr.json"},
+		{"ui/title{density}.png", "ui/title@2x.png"},
+		{"ui/dir{density}.png", "ui/dir.png"},
+		{"ui/other.png", "ui/other.png"},
+		{"ui/other{density}.png", ""},
+	}
+	for _, test := range tests {
+		got, err := core.resolveAsset(test.path)
+		if test.want == "" {
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("resolveAsset(%s) = %s, %v, want fs.ErrNotExist", test.path, got, err)
+			}
+		} else if err != nil || got != test.want {
+			t.Errorf("resolveAsset(%s) = %s, %v, want %s", test.path, got, err, test.want)
+		}
+	}
+
+	core.SetLocale("de")
+	if got, err := core.resolveAsset("strings/{locale}.json"); err != nil || got != "strings/en.json" {
+		t.Errorf("resolveAsset() after SetLocale() = %s, %v, want strings/en.json", got, err)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build windows
+// +build windows
+
+package tge
+
+import (
+	syscall "syscall"
+	unsafe "unsafe"
+)
+
+// Maximum length of a locale name including the terminating null character
+const localeNameMaxLength = 85
+
+var procGetUserDefaultLocaleName = syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+
+// systemLocale returns the locale of the current user
+func systemLocale() string {
+	if procGetUserDefaultLocaleName.Find() != nil {
+		return ""
+	}
+	buffer := make([]uint16, localeNameMaxLength)
+	if n, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buffer[0])), uintptr(len(buffer))); n == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buffer)
+}
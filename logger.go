@@ -0,0 +1,238 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	json "encoding/json"
+	fmt "fmt"
+	strings "strings"
+	sync "sync"
+	atomic "sync/atomic"
+)
+
+// LogLevel defines the minimum level of messages written by the Logger
+type LogLevel int
+
+// LogLevel values
+const (
+	// LogLevelDebug enables all messages
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo enables information, warning and error messages
+	LogLevelInfo
+	// LogLevelWarn enables warning and error messages
+	LogLevelWarn
+	// LogLevelError enables error messages only
+	LogLevelError
+	// LogLevelNone disables all messages
+	LogLevelNone
+)
+
+// String is Stringer implementation of LogLevel
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	case LogLevelNone:
+		return "NONE"
+	}
+	return "unknown"
+}
+
+// MarshalText is encoding.TextMarshaler implementation of LogLevel, levels are
+// written by name in settings files
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if l < LogLevelDebug || l > LogLevelNone {
+		return nil, fmt.Errorf("invalid log level %d", int(l))
+	}
+	return []byte(strings.ToLower(l.String())), nil
+}
+
+// UnmarshalText is encoding.TextUnmarshaler implementation of LogLevel, levels
+// are read by name (case insensitive) or by value
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	name := strings.ToUpper(strings.TrimSpace(string(text)))
+	for level := LogLevelDebug; level <= LogLevelNone; level++ {
+		if name == level.String() || name == fmt.Sprint(int(level)) {
+			*l = level
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid log level %q", string(text))
+}
+
+// UnmarshalJSON is json.Unmarshaler implementation of LogLevel, levels are read
+// from strings as UnmarshalText() or from numbers
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return l.UnmarshalText([]byte(name))
+	}
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid log level %s", string(data))
+	}
+	return l.UnmarshalText([]byte(fmt.Sprint(value)))
+}
+
+// Logger defines the leveled logging API of the Runtime. Each message can be completed
+// with key-value fields given as pairs:
+//
+//	logger.Info("Plugin loaded", "plugin", name)
+type Logger interface {
+	// Debug writes a message for development purpose
+	Debug(msg string, keyvals ...interface{})
+
+	// Info writes a message about normal Runtime activity
+	Info(msg string, keyvals ...interface{})
+
+	// Warn writes a message about an unexpected but recoverable situation
+	Warn(msg string, keyvals ...interface{})
+
+	// Error writes a message about a failure
+	Error(msg string, keyvals ...interface{})
+
+	// SetLevel changes the minimum level of written messages
+	SetLevel(level LogLevel)
+
+	// GetLevel returns the current minimum level of written messages
+	GetLevel() LogLevel
+}
+
+// Logger singleton, default implementation is set by each target
+var _logger = newLoggerHolder(newLogger())
+
+// SetLogger replaces the default Logger of the target, it should be called
+// before Run() to catch all Runtime and plugins messages. The level of logger
+// is kept, Settings.LogLevel only applies to the default Logger.
+func SetLogger(logger Logger) {
+	_logger.set(logger)
+}
+
+// loggerHolder forwards messages to the current Logger, it allows to replace
+// the Logger while Runtime goroutines are logging
+type loggerHolder struct {
+	current atomic.Value
+	custom  int32
+}
+
+// loggerBox gives the same concrete type to all loggers stored in atomic.Value
+type loggerBox struct {
+	logger Logger
+}
+
+func newLoggerHolder(logger Logger) *loggerHolder {
+	holder := &loggerHolder{}
+	holder.current.Store(loggerBox{logger})
+	return holder
+}
+
+func (h *loggerHolder) get() Logger {
+	return h.current.Load().(loggerBox).logger
+}
+
+func (h *loggerHolder) set(logger Logger) {
+	h.current.Store(loggerBox{logger})
+	atomic.StoreInt32(&h.custom, 1)
+}
+
+// setDefaultLevel applies the level of Settings if the Logger has not been replaced
+// using SetLogger()
+func (h *loggerHolder) setDefaultLevel(level LogLevel) {
+	if atomic.LoadInt32(&h.custom) == 0 {
+		h.get().SetLevel(level)
+	}
+}
+
+func (h *loggerHolder) Debug(msg string, keyvals ...interface{}) {
+	h.get().Debug(msg, keyvals...)
+}
+
+func (h *loggerHolder) Info(msg string, keyvals ...interface{}) {
+	h.get().Info(msg, keyvals...)
+}
+
+func (h *loggerHolder) Warn(msg string, keyvals ...interface{}) {
+	h.get().Warn(msg, keyvals...)
+}
+
+func (h *loggerHolder) Error(msg string, keyvals ...interface{}) {
+	h.get().Error(msg, keyvals...)
+}
+
+func (h *loggerHolder) SetLevel(level LogLevel) {
+	h.get().SetLevel(level)
+}
+
+func (h *loggerHolder) GetLevel() LogLevel {
+	return h.get().GetLevel()
+}
+
+// -------------------------------------------------------------------- //
+// Default implementation
+// -------------------------------------------------------------------- //
+
+// levelLogger handles level filtering and formatting for targets loggers,
+// formatted lines are then sent to the target specific writer
+type levelLogger struct {
+	mutex sync.RWMutex
+	level LogLevel
+	write func(level LogLevel, line string)
+}
+
+func (l *levelLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	if level >= l.GetLevel() {
+		l.write(level, formatLog(msg, keyvals))
+	}
+}
+
+func (l *levelLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LogLevelDebug, msg, keyvals)
+}
+
+func (l *levelLogger) Info(msg string, keyvals ...interface{}) {
+	l.log(LogLevelInfo, msg, keyvals)
+}
+
+func (l *levelLogger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LogLevelWarn, msg, keyvals)
+}
+
+func (l *levelLogger) Error(msg string, keyvals ...interface{}) {
+	l.log(LogLevelError, msg, keyvals)
+}
+
+func (l *levelLogger) SetLevel(level LogLevel) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.level = level
+}
+
+func (l *levelLogger) GetLevel() LogLevel {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.level
+}
+
+// formatLog builds a line of the form 'msg key1=value1 key2="value 2"'
+func formatLog(msg string, keyvals []interface{}) string {
+	var builder strings.Builder
+	builder.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "MISSING"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		str := fmt.Sprint(value)
+		if strings.ContainsAny(str, " \t\n\"=") {
+			str = fmt.Sprintf("%q", str)
+		}
+		fmt.Fprintf(&builder, " %v=%s", keyvals[i], str)
+	}
+	return builder.String()
+}
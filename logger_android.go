@@ -0,0 +1,43 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android
+// +build android
+
+package tge
+
+/*
+#cgo LDFLAGS: -llog
+
+#include <android/log.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	unsafe "unsafe"
+)
+
+// Logcat tag of TGE messages
+var logcatTag = C.CString("TGE")
+
+// newLogger creates the default Logger for android, messages are written
+// in logcat with the matching priority
+func newLogger() Logger {
+	return &levelLogger{
+		level: LogLevelInfo,
+		write: func(level LogLevel, line string) {
+			priority := C.int(C.ANDROID_LOG_INFO)
+			switch level {
+			case LogLevelDebug:
+				priority = C.ANDROID_LOG_DEBUG
+			case LogLevelWarn:
+				priority = C.ANDROID_LOG_WARN
+			case LogLevelError:
+				priority = C.ANDROID_LOG_ERROR
+			}
+			cLine := C.CString(line)
+			C.__android_log_write(priority, logcatTag, cLine)
+			C.free(unsafe.Pointer(cLine))
+		},
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	js "syscall/js"
+)
+
+// newLogger creates the default Logger for browser, messages are written
+// in the console using the method matching the level
+func newLogger() Logger {
+	return &levelLogger{
+		level: LogLevelInfo,
+		write: func(level LogLevel, line string) {
+			method := "info"
+			switch level {
+			case LogLevelDebug:
+				method = "debug"
+			case LogLevelWarn:
+				method = "warn"
+			case LogLevelError:
+				method = "error"
+			}
+			js.Global().Get("console").Call(method, line)
+		},
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !android && !js
+// +build !android,!js
+
+package tge
+
+import (
+	log "log"
+	os "os"
+)
+
+// newLogger creates the default Logger for desktop and ios, messages are
+// written on standard error
+func newLogger() Logger {
+	output := log.New(os.Stderr, "", log.LstdFlags)
+	return &levelLogger{
+		level: LogLevelInfo,
+		write: func(level LogLevel, line string) {
+			output.Printf("%-5s %s", level, line)
+		},
+	}
+}
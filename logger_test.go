@@ -0,0 +1,65 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	json "encoding/json"
+	testing "testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestLogLevelUnmarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		yaml  string
+		want  LogLevel
+		valid bool
+	}{
+		{"name", `{"log_level":"warn"}`, "log_level: warn", LogLevelWarn, true},
+		{"upper case name", `{"log_level":"ERROR"}`, "log_level: ERROR", LogLevelError, true},
+		{"number", `{"log_level":1}`, "log_level: 1", LogLevelInfo, true},
+		{"number as string", `{"log_level":"4"}`, `log_level: "4"`, LogLevelNone, true},
+		{"unknown name", `{"log_level":"verbose"}`, "log_level: verbose", 0, false},
+		{"unknown number", `{"log_level":5}`, "", 0, false},
+		{"float", `{"log_level":1.5}`, "", 0, false},
+		{"boolean", `{"log_level":true}`, "", 0, false},
+	}
+	for _, test := range tests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(test.json), &settings)
+		if test.valid && (err != nil || settings.LogLevel != test.want) {
+			t.Errorf("%s: JSON level = %s, %v, want %s", test.name, settings.LogLevel, err, test.want)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: JSON level should be rejected", test.name)
+		}
+		if test.yaml == "" {
+			continue
+		}
+		settings = Settings{}
+		err = yaml.Unmarshal([]byte(test.yaml), &settings)
+		if test.valid && (err != nil || settings.LogLevel != test.want) {
+			t.Errorf("%s: YAML level = %s, %v, want %s", test.name, settings.LogLevel, err, test.want)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: YAML level should be rejected", test.name)
+		}
+	}
+}
+
+func TestLogLevelMarshal(t *testing.T) {
+	for level := LogLevelDebug; level <= LogLevelNone; level++ {
+		data, err := json.Marshal(level)
+		if err != nil {
+			t.Errorf("Marshal(%s) failed: %s", level, err)
+			continue
+		}
+		var got LogLevel
+		if err := json.Unmarshal(data, &got); err != nil || got != level {
+			t.Errorf("Unmarshal(%s) = %s, %v, want %s", data, got, err, level)
+		}
+	}
+	if _, err := json.Marshal(LogLevelNone + 1); err == nil {
+		t.Error("Marshal() of an unknown level should fail")
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+/*
+Package pack implements the TGE asset pack format, an indexed archive allowing to ship
+many assets in a single file. Packs are built with Writer or Build() and mounted in the
+Runtime to be accessed transparently through GetAsset(), OpenAsset() or GetAssetFS().
+
+A pack is made of the entries content, followed by a JSON manifest and a fixed size footer:
+
+	+-----------------+----------------+--------------------------------------------+
+	| entries content | JSON manifest  | manifest offset | manifest length | magic  |
+	|                 |                | uint64 LE       | uint64 LE       | 8 bytes|
+	+-----------------+----------------+--------------------------------------------+
+
+Each entry of the manifest gives the position of its content in the pack, its compression
+and the SHA-256 of its uncompressed content which is checked each time the entry is opened.
+*/
+package pack // import "github.com/thommil/tge/pack"
+
+import (
+	bytes "bytes"
+	flate "compress/flate"
+	sha256 "crypto/sha256"
+	binary "encoding/binary"
+	hex "encoding/hex"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	io "io"
+	fs "io/fs"
+	ioutil "io/ioutil"
+	path "path"
+	sort "sort"
+	strings "strings"
+	time "time"
+)
+
+// Extension of pack files
+const Extension = ".tgepack"
+
+// Version of the pack format written by Writer
+const Version = 1
+
+// Magic value ending all packs
+const magic = "TGEPACK\x00"
+
+// Size of the footer in bytes
+const footerSize = 8 + 8 + len(magic)
+
+// ErrFormat is returned when a file is not a valid pack
+var ErrFormat = errors.New("pack: invalid format")
+
+// ErrChecksum is returned when the content of an entry does not match its SHA-256
+var ErrChecksum = errors.New("pack: checksum mismatch")
+
+// Compression defines how an entry content is stored in the pack
+type Compression byte
+
+// Compression values
+const (
+	// CompressionNone stores content as is, best for already compressed formats
+	CompressionNone Compression = 0
+	// CompressionDeflate stores content compressed with DEFLATE
+	CompressionDeflate Compression = 1
+)
+
+// String is Stringer implementation of Compression
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "CompressionNone"
+	case CompressionDeflate:
+		return "CompressionDeflate"
+	}
+	return "unknown"
+}
+
+// Entry describes a file stored in a pack
+type Entry struct {
+	// Path of the file in the assets tree
+	Path string `json:"path"`
+	// Offset of the stored content in the pack
+	Offset int64 `json:"offset"`
+	// Length of the stored content in the pack
+	Length int64 `json:"length"`
+	// Size of the uncompressed content
+	Size int64 `json:"size"`
+	// Compression of the stored content
+	Compression Compression `json:"compression"`
+	// SHA256 of the uncompressed content in hexadecimal form
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is the index of a pack
+type Manifest struct {
+	// Version of the pack format
+	Version int `json:"version"`
+	// Entries of the pack
+	Entries []Entry `json:"entries"`
+}
+
+// -------------------------------------------------------------------- //
+// Reader
+// -------------------------------------------------------------------- //
+
+// Pack is a read-only fs.FS on top of a pack file, it also implements
+// fs.ReadDirFS and fs.StatFS
+type Pack struct {
+	reader   io.ReaderAt
+	manifest Manifest
+	entries  map[string]*Entry
+	dirs     map[string][]fs.DirEntry
+}
+
+// Open reads the manifest of the pack available in reader
+func Open(reader io.ReaderAt, size int64) (*Pack, error) {
+	if size < int64(footerSize) {
+		return nil, ErrFormat
+	}
+	footer := make([]byte, footerSize)
+	if _, err := reader.ReadAt(footer, size-int64(footerSize)); err != nil {
+		return nil, err
+	}
+	if string(footer[16:]) != magic {
+		return nil, ErrFormat
+	}
+	offset := int64(binary.LittleEndian.Uint64(footer[0:8]))
+	length := int64(binary.LittleEndian.Uint64(footer[8:16]))
+	if offset < 0 || length < 0 || offset+length > size-int64(footerSize) {
+		return nil, ErrFormat
+	}
+
+	pack := &Pack{
+		reader:  reader,
+		entries: make(map[string]*Entry),
+		dirs:    map[string][]fs.DirEntry{".": nil},
+	}
+	if err := json.NewDecoder(io.NewSectionReader(reader, offset, length)).Decode(&pack.manifest); err != nil {
+		return nil, fmt.Errorf("pack: invalid manifest: %v", err)
+	}
+	if pack.manifest.Version > Version {
+		return nil, fmt.Errorf("pack: unsupported version %d", pack.manifest.Version)
+	}
+
+	for i := range pack.manifest.Entries {
+		entry := &pack.manifest.Entries[i]
+		if !fs.ValidPath(entry.Path) || entry.Path == "." || entry.Offset < 0 || entry.Length < 0 || entry.Offset+entry.Length > offset {
+			return nil, fmt.Errorf("pack: invalid entry %q", entry.Path)
+		}
+		if _, found := pack.entries[entry.Path]; found {
+			return nil, fmt.Errorf("pack: duplicate entry %q", entry.Path)
+		}
+		pack.entries[entry.Path] = entry
+		pack.addToDirs(entry.Path, entryInfo{entry})
+	}
+	for _, list := range pack.dirs {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	}
+	return pack, nil
+}
+
+// addToDirs registers name in its parent directory, parents are created recursively
+func (pack *Pack) addToDirs(name string, info fs.FileInfo) {
+	dir := path.Dir(name)
+	_, found := pack.dirs[dir]
+	pack.dirs[dir] = append(pack.dirs[dir], fs.FileInfoToDirEntry(info))
+	if !found && dir != "." {
+		pack.addToDirs(dir, dirInfo{path.Base(dir)})
+	}
+}
+
+// Manifest returns the manifest of the pack
+func (pack *Pack) Manifest() Manifest {
+	return pack.manifest
+}
+
+// Open opens the named file or directory, the content of files is checked
+// against their SHA-256 before being returned
+func (pack *Pack) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if list, found := pack.dirs[name]; found {
+		return &dir{info: dirInfo{path.Base(name)}, entries: list}, nil
+	}
+	entry, found := pack.entries[name]
+	if !found {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	content, err := pack.read(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{info: entryInfo{entry}, Reader: bytes.NewReader(content)}, nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (pack *Pack) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	list, found := pack.dirs[name]
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]fs.DirEntry(nil), list...), nil
+}
+
+// Stat implements fs.StatFS
+func (pack *Pack) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, found := pack.dirs[name]; found {
+		return dirInfo{path.Base(name)}, nil
+	}
+	if entry, found := pack.entries[name]; found {
+		return entryInfo{entry}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// read loads, uncompresses and checks the content of entry
+func (pack *Pack) read(entry *Entry) ([]byte, error) {
+	var reader io.Reader = io.NewSectionReader(pack.reader, entry.Offset, entry.Length)
+	switch entry.Compression {
+	case CompressionNone:
+	case CompressionDeflate:
+		decompressor := flate.NewReader(reader)
+		defer decompressor.Close()
+		reader = decompressor
+	default:
+		return nil, fmt.Errorf("pack: unsupported compression %d", entry.Compression)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(reader, entry.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	if int64(len(content)) != entry.Size || !strings.EqualFold(hex.EncodeToString(sum[:]), entry.SHA256) {
+		return nil, ErrChecksum
+	}
+	return content, nil
+}
+
+// file is an opened entry of the pack
+type file struct {
+	*bytes.Reader
+	info entryInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+// dir is an opened directory of the pack
+type dir struct {
+	info    dirInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := len(d.entries) - d.offset
+	if count > 0 && remaining == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < remaining {
+		remaining = count
+	}
+	list := d.entries[d.offset : d.offset+remaining]
+	d.offset += remaining
+	return list, nil
+}
+
+// entryInfo is the fs.FileInfo of an entry
+type entryInfo struct {
+	entry *Entry
+}
+
+func (i entryInfo) Name() string      { return path.Base(i.entry.Path) }
+func (i entryInfo) Size() int64       { return i.entry.Size }
+func (i entryInfo) Mode() fs.FileMode { return 0444 }
+func (i entryInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i entryInfo) IsDir() bool      { return false }
+func (i entryInfo) Sys() interface{} { return i.entry }
+
+// dirInfo is the fs.FileInfo of a directory
+type dirInfo struct {
+	name string
+}
+
+func (i dirInfo) Name() string      { return i.name }
+func (i dirInfo) Size() int64       { return 0 }
+func (i dirInfo) Mode() fs.FileMode { return fs.ModeDir | 0555 }
+func (i dirInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i dirInfo) IsDir() bool      { return true }
+func (i dirInfo) Sys() interface{} { return nil }
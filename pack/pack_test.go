@@ -0,0 +1,90 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package pack
+
+import (
+	bytes "bytes"
+	fs "io/fs"
+	os "os"
+	filepath "path/filepath"
+	testing "testing"
+	fstest "testing/fstest"
+)
+
+func TestBuildDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"config.json":        []byte(`{"name":"test"}`),
+		"images/sprite.png":  bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 256),
+		"shaders/main.vert":  bytes.Repeat([]byte("void main() {}\n"), 64),
+		"shaders/lib/common": []byte(""),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, "assets", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := filepath.Join(dir, "assets"+Extension)
+	if err := BuildDir(filepath.Join(dir, "assets"), target); err != nil {
+		t.Fatalf("BuildDir() failed: %s", err)
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Open(file, info.Size())
+	if err != nil {
+		t.Fatalf("Open() failed: %s", err)
+	}
+
+	manifest := p.Manifest()
+	if manifest.Version != Version {
+		t.Errorf("Manifest().Version = %d, want %d", manifest.Version, Version)
+	}
+	if len(manifest.Entries) != len(files) {
+		t.Errorf("Manifest().Entries has %d entries, want %d", len(manifest.Entries), len(files))
+	}
+
+	expected := make([]string, 0, len(files))
+	for name, content := range files {
+		expected = append(expected, name)
+		data, err := fs.ReadFile(p, name)
+		if err != nil {
+			t.Errorf("ReadFile(%s) failed: %s", name, err)
+			continue
+		}
+		if !bytes.Equal(data, content) {
+			t.Errorf("ReadFile(%s) returned %d bytes not matching the %d bytes packed", name, len(data), len(content))
+		}
+	}
+
+	entries, err := p.ReadDir("shaders")
+	if err != nil {
+		t.Fatalf("ReadDir(shaders) failed: %s", err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "lib" || !entries[0].IsDir() || entries[1].Name() != "main.vert" {
+		t.Errorf("ReadDir(shaders) returned unexpected entries %v", entries)
+	}
+
+	if err := fstest.TestFS(p, expected...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	data := []byte("not a pack at all, not a pack at all")
+	if _, err := Open(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Open() of an invalid pack should fail")
+	}
+}
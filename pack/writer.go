@@ -0,0 +1,171 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package pack
+
+import (
+	flate "compress/flate"
+	sha256 "crypto/sha256"
+	binary "encoding/binary"
+	hex "encoding/hex"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	io "io"
+	fs "io/fs"
+	os "os"
+	path "path"
+	strings "strings"
+)
+
+// Extensions of formats already compressed, stored without compression by Build()
+var compressedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".ogg": true, ".mp3": true, ".m4a": true, ".opus": true,
+	".mp4": true, ".webm": true, ".mkv": true,
+	".gz": true, ".zip": true, ".bz2": true, ".xz": true,
+	Extension: true,
+}
+
+// DefaultCompression returns the Compression used by Build() for name, already
+// compressed formats are stored as is and others are deflated
+func DefaultCompression(name string) Compression {
+	if compressedExtensions[strings.ToLower(path.Ext(name))] {
+		return CompressionNone
+	}
+	return CompressionDeflate
+}
+
+// Writer creates a pack by appending entries one after the other, Close() must
+// be called to write the manifest
+type Writer struct {
+	writer   *countWriter
+	manifest Manifest
+	paths    map[string]bool
+	closed   bool
+}
+
+// NewWriter creates a Writer of pack on w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		writer:   &countWriter{writer: w},
+		manifest: Manifest{Version: Version},
+		paths:    make(map[string]bool),
+	}
+}
+
+// Add appends the content read from reader as the entry name using compression
+func (w *Writer) Add(name string, reader io.Reader, compression Compression) error {
+	if w.closed {
+		return errors.New("pack: writer closed")
+	}
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("pack: invalid entry %q", name)
+	}
+	if w.paths[name] {
+		return fmt.Errorf("pack: duplicate entry %q", name)
+	}
+
+	entry := Entry{
+		Path:        name,
+		Offset:      w.writer.count,
+		Compression: compression,
+	}
+	hash := sha256.New()
+	var target io.Writer = w.writer
+	var compressor *flate.Writer
+	switch compression {
+	case CompressionNone:
+	case CompressionDeflate:
+		compressor, _ = flate.NewWriter(w.writer, flate.BestCompression)
+		target = compressor
+	default:
+		return fmt.Errorf("pack: unsupported compression %d", compression)
+	}
+
+	size, err := io.Copy(io.MultiWriter(target, hash), reader)
+	if err != nil {
+		return err
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+	}
+
+	entry.Size = size
+	entry.Length = w.writer.count - entry.Offset
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	w.manifest.Entries = append(w.manifest.Entries, entry)
+	w.paths[name] = true
+	return nil
+}
+
+// Close writes the manifest and the footer of the pack, it does not close the
+// underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	offset := w.writer.count
+	if err := json.NewEncoder(w.writer).Encode(w.manifest); err != nil {
+		return err
+	}
+	footer := make([]byte, footerSize)
+	binary.LittleEndian.PutUint64(footer[0:8], uint64(offset))
+	binary.LittleEndian.PutUint64(footer[8:16], uint64(w.writer.count-offset))
+	copy(footer[16:], magic)
+	_, err := w.writer.Write(footer)
+	return err
+}
+
+// Build creates a pack on w with all the files of fsys, compression is chosen
+// using DefaultCompression() and existing packs are ignored
+func Build(w io.Writer, fsys fs.FS) error {
+	writer := NewWriter(w)
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) == Extension {
+			return err
+		}
+		file, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return writer.Add(name, file, DefaultCompression(name))
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// BuildDir creates the pack file target with all the files of the directory dir,
+// it is intended to pack the assets folder of an application:
+//
+//	err := pack.BuildDir("assets", "dist/assets"+pack.Extension)
+func BuildDir(dir string, target string) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if err = Build(file, os.DirFS(dir)); err != nil {
+		file.Close()
+		os.Remove(target)
+		return err
+	}
+	return file.Close()
+}
+
+// countWriter counts the bytes written to give entries offsets
+type countWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
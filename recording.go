@@ -0,0 +1,328 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bufio "bufio"
+	json "encoding/json"
+	fmt "fmt"
+	io "io"
+	reflect "reflect"
+	sync "sync"
+	time "time"
+)
+
+// Version of the recording format, written in the header line
+const recordingVersion = 1
+
+// Input events stored in recordings, indexed by their name in the recording. Other
+// events (resize, assets, gestures ...) are produced again by the Runtime at replay.
+var recordedEvents = map[string]reflect.Type{
+	"mouse":   reflect.TypeOf(MouseEvent{}),
+	"scroll":  reflect.TypeOf(ScrollEvent{}),
+	"key":     reflect.TypeOf(KeyEvent{}),
+	"text":    reflect.TypeOf(TextInputEvent{}),
+	"gamepad": reflect.TypeOf(GamepadEvent{}),
+}
+
+// recordedEventName returns the name of event in recordings, "" if it is not recorded
+func recordedEventName(event Event) string {
+	eventType := reflect.TypeOf(event)
+	for name, recordedType := range recordedEvents {
+		if recordedType == eventType {
+			return name
+		}
+	}
+	return ""
+}
+
+// recordLine is a line of a recording, either the header, an event or the end of a tick.
+// Time is the duration in nanoseconds since the start of the recording.
+type recordLine struct {
+	Version int             `json:"version,omitempty"`
+	Tick    int64           `json:"tick"`
+	Time    int64           `json:"time"`
+	Event   string          `json:"event,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Elapsed *int64          `json:"elapsed,omitempty"`
+}
+
+// WithRecording records the input events published by the Runtime in w, the recording
+// can then be replayed using WithReplay() to reproduce a session
+func WithRecording(w io.Writer) Option {
+	return func(opts *options) {
+		opts.recording = w
+	}
+}
+
+// WithReplay replays a recording made with WithRecording(), live input events are
+// ignored until the end of the recording
+func WithReplay(r io.Reader) Option {
+	return func(opts *options) {
+		opts.replay = r
+	}
+}
+
+// initRecording starts the recorder and the replayer from options or from the files
+// of user data given in Settings.Record and Settings.Replay
+func (core *runtimeCore) initRecording(opts *options, settings Settings) error {
+	replay, record := opts.replay, opts.recording
+	var closers []io.Closer
+	if replay == nil && settings.Replay != "" {
+		file, err := core.userFS.Open(settings.Replay)
+		if err != nil {
+			return fmt.Errorf("failed to open replay %s: %w", settings.Replay, err)
+		}
+		replay = file
+		closers = append(closers, file)
+	}
+	if record == nil && settings.Record != "" {
+		file, err := core.userFS.Create(settings.Record)
+		if err != nil {
+			return fmt.Errorf("failed to create recording %s: %w", settings.Record, err)
+		}
+		record = file
+		closers = append(closers, file)
+	}
+	core.recordingClosers = closers
+	if replay != nil {
+		replayer, err := newReplayer(replay)
+		if err != nil {
+			return err
+		}
+		core.replayer = replayer
+		_logger.Info("Replay started")
+	}
+	if record != nil {
+		recorder, err := newRecorder(record)
+		if err != nil {
+			return err
+		}
+		core.recorder = recorder
+		_logger.Info("Recording started")
+	}
+	return nil
+}
+
+// beginTick prepares the Input of a new tick: events of the tick are replayed, long
+// presses are recognized, the snapshot is taken and the tick is recorded. It returns
+// the elapsed time to give to OnTick(), which is the recorded one during replay.
+func (core *runtimeCore) beginTick(elapsedTime time.Duration) time.Duration {
+	if core.replayer != nil {
+		if elapsed, ok := core.replayer.replayTick(core.publish); ok {
+			elapsedTime = elapsed
+		}
+	}
+	// Long press is checked at each tick as it is not triggered by an event
+	now := core.now()
+	if core.isEventEnabled(GestureEventEnabled) {
+		core.gestures.poll(now)
+	}
+	core.tickMutex.Lock()
+	defer core.tickMutex.Unlock()
+	core.input.snapshot()
+	if core.recorder != nil {
+		if err := core.recorder.recordTick(elapsedTime, now); err != nil {
+			_logger.Error("Failed to record tick", "error", err)
+			core.recorder = nil
+		}
+	}
+	return elapsedTime
+}
+
+// now returns the time of input events, it is the recorded time during replay to
+// recognize gestures as in the recorded session
+func (core *runtimeCore) now() time.Time {
+	if core.replayer != nil {
+		if now, ok := core.replayer.now(); ok {
+			return now
+		}
+	}
+	return time.Now()
+}
+
+// flushRecording writes the buffered recording, the recording goes on
+func (core *runtimeCore) flushRecording() {
+	core.tickMutex.Lock()
+	defer core.tickMutex.Unlock()
+	if core.recorder != nil {
+		if err := core.recorder.flush(); err != nil {
+			_logger.Error("Failed to write recording", "error", err)
+		}
+	}
+}
+
+// disposeRecording flushes the recording and closes the files opened from Settings,
+// it is called once at the end of Run as the recording spans focus changes
+func (core *runtimeCore) disposeRecording() {
+	core.tickMutex.Lock()
+	defer core.tickMutex.Unlock()
+	if core.recorder != nil {
+		if err := core.recorder.flush(); err != nil {
+			_logger.Error("Failed to write recording", "error", err)
+		}
+		core.recorder = nil
+	}
+	for _, closer := range core.recordingClosers {
+		if err := closer.Close(); err != nil {
+			_logger.Error("Failed to close recording", "error", err)
+		}
+	}
+	core.recordingClosers = nil
+}
+
+// -------------------------------------------------------------------- //
+// Recorder
+// -------------------------------------------------------------------- //
+
+// recorder writes input events in JSON lines, each event is followed by the
+// end of the tick which received it
+type recorder struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	start   time.Time
+	tick    int64
+}
+
+func newRecorder(w io.Writer) (*recorder, error) {
+	writer := bufio.NewWriter(w)
+	r := &recorder{writer: writer, encoder: json.NewEncoder(writer), start: time.Now()}
+	if err := r.encoder.Encode(recordLine{Version: recordingVersion}); err != nil {
+		return nil, err
+	}
+	return r, r.writer.Flush()
+}
+
+// recordEvent writes event received at now if it is an input event, the tick mutex
+// must be held
+func (r *recorder) recordEvent(event Event, now time.Time) error {
+	name := recordedEventName(event)
+	if name == "" {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.encoder.Encode(recordLine{Tick: r.tick, Time: int64(now.Sub(r.start)), Event: name, Data: data})
+}
+
+// recordTick writes the end of the current tick at now, the tick mutex must be held.
+// The recording is flushed at each tick to keep it usable after a crash.
+func (r *recorder) recordTick(elapsedTime time.Duration, now time.Time) error {
+	elapsed := int64(elapsedTime)
+	if err := r.encoder.Encode(recordLine{Tick: r.tick, Time: int64(now.Sub(r.start)), Elapsed: &elapsed}); err != nil {
+		return err
+	}
+	r.tick++
+	return r.writer.Flush()
+}
+
+func (r *recorder) flush() error {
+	return r.writer.Flush()
+}
+
+// -------------------------------------------------------------------- //
+// Replayer
+// -------------------------------------------------------------------- //
+
+// replayer reads a recording and publishes its events tick by tick, its clock follows
+// the recorded times from the start of the replay
+type replayer struct {
+	mutex   sync.Mutex
+	scanner *bufio.Scanner
+	tick    int64
+	done    bool
+	start   time.Time
+	clock   time.Time
+}
+
+func newReplayer(r io.Reader) (*replayer, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	now := time.Now()
+	p := &replayer{scanner: scanner, start: now, clock: now}
+	line, err := p.next()
+	if err == nil && line.Version != recordingVersion {
+		err = fmt.Errorf("unsupported recording version %d", line.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording: %w", err)
+	}
+	return p, nil
+}
+
+// isReplaying indicates if the recording is not finished, live input events are then ignored
+func (p *replayer) isReplaying() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return !p.done
+}
+
+// now returns the recorded time of the last line read, false is returned at the end
+// of the recording
+func (p *replayer) now() (time.Time, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.clock, !p.done
+}
+
+// replayTick publishes the events of the next tick and returns its recorded elapsed
+// time, false is returned at the end of the recording
+func (p *replayer) replayTick(publish func(event Event)) (time.Duration, bool) {
+	if !p.isReplaying() {
+		return 0, false
+	}
+	for {
+		line, err := p.next()
+		if err == nil && line.Tick != p.tick {
+			err = fmt.Errorf("unexpected tick %d", line.Tick)
+		}
+		if err != nil {
+			if err != io.EOF {
+				_logger.Error("Failed to read recording", "tick", p.tick, "error", err)
+			}
+			p.finish()
+			return 0, false
+		}
+		p.mutex.Lock()
+		p.clock = p.start.Add(time.Duration(line.Time))
+		p.mutex.Unlock()
+		if line.Elapsed != nil {
+			p.tick++
+			return time.Duration(*line.Elapsed), true
+		}
+		eventType, found := recordedEvents[line.Event]
+		if !found {
+			_logger.Warn("Unknown event in recording", "tick", p.tick, "event", line.Event)
+			continue
+		}
+		event := reflect.New(eventType)
+		if err := json.Unmarshal(line.Data, event.Interface()); err != nil {
+			_logger.Error("Failed to read recording", "tick", p.tick, "error", err)
+			p.finish()
+			return 0, false
+		}
+		publish(event.Elem().Interface().(Event))
+	}
+}
+
+// finish ends the replay, live input events are then published again
+func (p *replayer) finish() {
+	p.mutex.Lock()
+	p.done = true
+	p.mutex.Unlock()
+	_logger.Info("Replay finished", "ticks", p.tick)
+}
+
+// next reads the next line of the recording
+func (p *replayer) next() (recordLine, error) {
+	line := recordLine{}
+	if !p.scanner.Scan() {
+		if err := p.scanner.Err(); err != nil {
+			return line, err
+		}
+		return line, io.EOF
+	}
+	return line, json.Unmarshal(p.scanner.Bytes(), &line)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bytes "bytes"
+	reflect "reflect"
+	strings "strings"
+	testing "testing"
+	time "time"
+)
+
+// recordedTick is a tick of a recording test with its events and their delay
+type recordedTick struct {
+	events  []Event
+	elapsed time.Duration
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		ticks []recordedTick
+	}{
+		{"empty", nil},
+		{"no events", []recordedTick{{elapsed: 16 * time.Millisecond}, {elapsed: 17 * time.Millisecond}}},
+		{"inputs", []recordedTick{
+			{events: []Event{MouseEvent{Button: TouchFirst, Type: TypeDown, X: 5, Y: 6}}, elapsed: 16 * time.Millisecond},
+			{events: []Event{
+				KeyEvent{Key: KeyCodeA, Value: "a", Type: TypeDown},
+				TextInputEvent{Text: "a"},
+				ScrollEvent{X: 1, Y: -2},
+			}, elapsed: 20 * time.Millisecond},
+			{elapsed: 16 * time.Millisecond},
+			{events: []Event{MouseEvent{Button: TouchFirst, Type: TypeUp, X: 7, Y: 8}}, elapsed: 15 * time.Millisecond},
+		}},
+		{"not recorded", []recordedTick{
+			{events: []Event{ResizeEvent{Width: 10, Height: 10}, GestureEvent{Gesture: GestureTap}}, elapsed: 16 * time.Millisecond},
+		}},
+	}
+	for _, test := range tests {
+		var buffer bytes.Buffer
+		r, err := newRecorder(&buffer)
+		if err != nil {
+			t.Fatalf("%s: newRecorder() failed: %s", test.name, err)
+		}
+		now := r.start
+		var want []Event
+		var wantTimes []time.Duration
+		for _, tick := range test.ticks {
+			for _, event := range tick.events {
+				now = now.Add(time.Millisecond)
+				if err := r.recordEvent(event, now); err != nil {
+					t.Fatalf("%s: recordEvent() failed: %s", test.name, err)
+				}
+				if recordedEventName(event) != "" {
+					want = append(want, event)
+					wantTimes = append(wantTimes, now.Sub(r.start))
+				}
+			}
+			now = now.Add(tick.elapsed)
+			if err := r.recordTick(tick.elapsed, now); err != nil {
+				t.Fatalf("%s: recordTick() failed: %s", test.name, err)
+			}
+		}
+
+		p, err := newReplayer(bytes.NewReader(buffer.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: newReplayer() failed: %s", test.name, err)
+		}
+		var got []Event
+		var gotTimes []time.Duration
+		publish := func(event Event) {
+			clock, _ := p.now()
+			got = append(got, event)
+			gotTimes = append(gotTimes, clock.Sub(p.start))
+		}
+		for i, tick := range test.ticks {
+			elapsed, ok := p.replayTick(publish)
+			if !ok || elapsed != tick.elapsed {
+				t.Errorf("%s: replayTick() at tick %d = %s, %v, want %s, true", test.name, i, elapsed, ok, tick.elapsed)
+			}
+		}
+		if _, ok := p.replayTick(publish); ok || p.isReplaying() {
+			t.Errorf("%s: replay should be finished after %d ticks", test.name, len(test.ticks))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: replayed events %v, want %v", test.name, got, want)
+		}
+		if !reflect.DeepEqual(gotTimes, wantTimes) {
+			t.Errorf("%s: replayed times %v, want %v", test.name, gotTimes, wantTimes)
+		}
+	}
+}
+
+func TestReplayerInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		recording string
+	}{
+		{"empty", ""},
+		{"not JSON", "recording\n"},
+		{"version", `{"version":2,"tick":0,"time":0}` + "\n"},
+	}
+	for _, test := range tests {
+		if _, err := newReplayer(strings.NewReader(test.recording)); err == nil {
+			t.Errorf("%s: newReplayer() should fail", test.name)
+		}
+	}
+}
+
+// recordingCloser counts the calls to Close()
+type recordingCloser struct {
+	closed int
+}
+
+func (c *recordingCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestRecordingSpansDispose(t *testing.T) {
+	core := &runtimeCore{}
+	if err := core.initCore(&options{}); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	closer := &recordingCloser{}
+	if err := core.initRecording(&options{recording: &buffer}, Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	core.recordingClosers = append(core.recordingClosers, closer)
+
+	// Focus changes of mobile targets dispose plugins but keep recording
+	core.dispose()
+	if core.recorder == nil || closer.closed != 0 {
+		t.Fatal("dispose() should not stop the recording")
+	}
+	core.beginTick(time.Millisecond)
+	core.disposeRecording()
+	if core.recorder != nil || closer.closed != 1 {
+		t.Fatal("disposeRecording() should stop the recording")
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != 2 {
+		t.Errorf("recording has %d lines, want 2", lines)
+	}
+}
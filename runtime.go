@@ -4,13 +4,16 @@ package tge
 
 import (
 	fmt "fmt"
+	io "io"
+	fs "io/fs"
 	reflect "reflect"
+	sort "sort"
+	strings "strings"
+	sync "sync"
+	atomic "sync/atomic"
 	time "time"
 )
 
-// Runtime singleton
-var _runtimeInstance Runtime
-
 // -------------------------------------------------------------------- //
 // API
 // -------------------------------------------------------------------- //
@@ -18,9 +21,61 @@ var _runtimeInstance Runtime
 // Runtime defines the commmon API across runtimes implementations
 type Runtime interface {
 	// GetAsset retrieves assets in []byte form, assets are always stored in
-	// package asset folder independently of the target
+	// package asset folder independently of the target. Contents are loaded
+	// through the AssetCache and a copy is returned to the caller.
 	GetAsset(path string) ([]byte, error)
 
+	// GetAssetCache returns the cache used by GetAsset to pin, release or
+	// monitor cached assets, its Get() method gives a shared access to contents
+	// without copy
+	GetAssetCache() *AssetCache
+
+	// OpenAsset opens an asset for streaming, it should be preferred to GetAsset for
+	// large files (music, video ...) as content is read on demand
+	OpenAsset(path string) (io.ReadSeekCloser, error)
+
+	// GetAssetFS returns the assets tree as a read-only file system, the returned value
+	// also implements fs.ReadDirFS and fs.StatFS (directories listing is not available
+	// on mobile and browser targets)
+	GetAssetFS() fs.FS
+
+	// MountAssetPack mounts an asset pack (see package pack) over the assets tree, its
+	// entries are then available through all assets functions and take precedence
+	// over the files already available
+	MountAssetPack(path string) error
+
+	// LoadAssetAsync starts loading assets in background and returns immediately, the
+	// progression is published as AssetProgressEvent on "asset" channel
+	LoadAssetAsync(paths ...string) *AssetLoading
+
+	// LoadAs loads an asset and decodes it into target using the decoder registered
+	// for its extension or MIME type. Default decoders support PNG/JPEG/GIF into
+	// *image.Image, JSON/YAML into structs and WAV/OGG headers into *AudioInfo.
+	LoadAs(path string, target interface{}) error
+
+	// RegisterDecoder registers a decoder for the given extensions (".png") or MIME
+	// types ("image/png"), existing decoders are replaced
+	RegisterDecoder(decoder Decoder, keys ...string)
+
+	// GetLocale returns the current locale in BCP 47 form (fr-FR), it is used to
+	// resolve the {locale} placeholder of assets paths (see package description)
+	GetLocale() string
+
+	// SetLocale changes the locale used to resolve assets variants
+	SetLocale(locale string)
+
+	// GetPixelDensity returns the ratio between physical and logical pixels, it is
+	// used to resolve the {density} placeholder of assets paths (title{density}.png)
+	GetPixelDensity() float32
+
+	// GetStorage returns the persistent key-value store of the App, changes are
+	// persisted by calling Commit()
+	GetStorage() *Storage
+
+	// GetUserFS returns a writable file system rooted at the user data directory
+	// of the App to store save files, screenshots or logs
+	GetUserFS() *UserFS
+
 	// GetHost is for low level and target specific implementation and allows to
 	// retrieve the underlying backend of the Runtime (see package description)
 	GetHost() interface{}
@@ -32,6 +87,33 @@ type Runtime interface {
 	// GetSettings returns the current Runtime settings
 	GetSettings() Settings
 
+	// GetInput returns the state of input devices taken before the current OnTick(),
+	// including the keys and buttons pressed or released since the previous one
+	GetInput() *Input
+
+	// GetActions returns the mapping of App actions to inputs, bindings are loaded
+	// from Settings.Actions and actions states can be polled in OnTick()
+	GetActions() *Actions
+
+	// SetGestureThresholds changes the limits used to recognize gestures published
+	// as GestureEvent on "gesture" channel (see DefaultGestureThresholds)
+	SetGestureThresholds(thresholds GestureThresholds)
+
+	// SetEventMask enables/disables events receivers immediately, it replaces the
+	// Settings.EventMask value (ex: enable text input only while a text field is focused)
+	SetEventMask(mask EventMask)
+
+	// ShowKeyboard shows the virtual keyboard on mobile and browser targets, typed text
+	// is published as TextInputEvent. It does nothing on desktop.
+	ShowKeyboard(keyboardType KeyboardType)
+
+	// HideKeyboard hides the virtual keyboard shown by ShowKeyboard
+	HideKeyboard()
+
+	// GetLogger returns the Logger of the Runtime, Apps and plugins should use it
+	// instead of writing directly on standard outputs
+	GetLogger() Logger
+
 	// Subscribe register a new Listener to specified channel
 	Subscribe(channel string, listener Listener)
 
@@ -41,6 +123,21 @@ type Runtime interface {
 	// Publish send an Event on channel defined in the Event.Channel()
 	Publish(event Event)
 
+	// GetPlugin retrieves a registered plugin by its name
+	GetPlugin(name string) (Plugin, bool)
+
+	// RegisterService publishes a service (ex: "gl", "audio", "gesture") to be discovered
+	// by App and other plugins, an error is returned if the name is already used
+	RegisterService(name string, service interface{}) error
+
+	// UnregisterService removes a service previously published with RegisterService
+	UnregisterService(name string)
+
+	// GetService retrieves a service published with RegisterService and stores it in the value
+	// pointed by target, an error is returned if the service is not found or if its type
+	// is not assignable to target
+	GetService(name string, target interface{}) error
+
 	// Stop allows App to end the Runtime directly
 	Stop()
 }
@@ -100,37 +197,392 @@ type Plugin interface {
 	Dispose()
 }
 
-// Inner map of plugins
-var plugins = make(map[string]Plugin)
+// DependentPlugin is an optional interface of Plugin to declare the names of plugins
+// which must be initialized before it (ex: tge-g3n depends on tge-gl)
+type DependentPlugin interface {
+	Plugin
+
+	// Dependencies returns the names of the required plugins
+	Dependencies() []string
+}
+
+// Pausable is an optional interface of Plugin to be notified when the Runtime is paused,
+// plugins are paused after the App
+type Pausable interface {
+	Plugin
+
+	// OnPause is called when the Runtime lose focus
+	OnPause()
+}
+
+// Resumable is an optional interface of Plugin to be notified when the Runtime is resumed,
+// plugins are resumed before the App
+type Resumable interface {
+	Plugin
+
+	// OnResume is called when the Runtime is started and when it is awaken after a pause
+	OnResume()
+}
+
+// Resizable is an optional interface of Plugin to be notified when the painting area is resized,
+// plugins are notified before the ResizeEvent is published
+type Resizable interface {
+	Plugin
+
+	// OnResize is called with the new size of the painting area
+	OnResize(width, height int32)
+}
+
+// FrameHook is an optional interface of Plugin to be called around each App OnRender()
+// and OnTick() calls, the elapsed time is the one given to the App
+type FrameHook interface {
+	Plugin
+
+	// PreRender is called in Render loop before App OnRender()
+	PreRender(elapsedTime time.Duration)
+
+	// PostRender is called in Render loop after App OnRender()
+	PostRender(elapsedTime time.Duration)
+
+	// PreTick is called in Ticker loop before App OnTick()
+	PreTick(elapsedTime time.Duration)
+
+	// PostTick is called in Ticker loop after App OnTick()
+	PostTick(elapsedTime time.Duration)
+}
+
+// Plugins registered globally using Register()
+var registeredPlugins = make(map[string]Plugin)
 
 // Register a plugin in Runtime, this function should only be called
 // in the Go init() function of plugins to allow registration of plugins
 // before looping. In other case, the Init() method of plugins are never called.
-func Register(plugin Plugin) {
+//
+// Plugins registered this way are available in all Runtimes, to enable plugins
+// for a single Runtime, use the WithPlugins() option of Run().
+//
+// An error is returned if another plugin has already been registered with the same name.
+func Register(plugin Plugin) error {
 	name := plugin.GetName()
-	if _, found := plugins[name]; !found {
-		plugins[name] = plugin
-		fmt.Printf("Plugin %s registered\n", name)
+	if _, found := registeredPlugins[name]; found {
+		err := fmt.Errorf("plugin %s already registered", name)
+		_logger.Warn("Failed to register plugin", "plugin", name, "error", err)
+		return err
+	}
+	registeredPlugins[name] = plugin
+	_logger.Info("Plugin registered", "plugin", name)
+	return nil
+}
+
+// -------------------------------------------------------------------- //
+// Options
+// -------------------------------------------------------------------- //
+
+// Option allows to customize the Runtime created by Run()
+type Option func(opts *options)
+
+// Inner options of Run()
+type options struct {
+	plugins   []Plugin
+	assets    fs.FS
+	assetsDir string
+	recording io.Writer
+	replay    io.Reader
+}
+
+// WithPlugins adds plugins to the Runtime in addition to the ones registered
+// using Register(), it allows to enable plugins conditionally
+func WithPlugins(plugins ...Plugin) Option {
+	return func(opts *options) {
+		opts.plugins = append(opts.plugins, plugins...)
+	}
+}
+
+// WithAssets replaces the default assets location by fsys, it allows to embed assets
+// in the binary using go:embed:
+//
+//	//go:embed assets
+//	var embedded embed.FS
+//
+//	assets, _ := fs.Sub(embedded, "assets")
+//	tge.Run(app, tge.WithAssets(assets))
+func WithAssets(fsys fs.FS) Option {
+	return func(opts *options) {
+		opts.assets = fsys
+	}
+}
+
+// WithAssetsDir sets the directory of assets on desktop, relative paths are resolved
+// from the working directory. It is ignored on other targets.
+func WithAssetsDir(dir string) Option {
+	return func(opts *options) {
+		opts.assetsDir = dir
+	}
+}
+
+func newOptions(optionList []Option) *options {
+	opts := &options{}
+	for _, option := range optionList {
+		option(opts)
+	}
+	return opts
+}
+
+// -------------------------------------------------------------------- //
+// Core
+// -------------------------------------------------------------------- //
+
+// runtimeCore holds the target independent state of a Runtime (assets, plugins,
+// services and listeners), it is embedded by all Runtime implementations
+type runtimeCore struct {
+	assets           fs.FS
+	baseAssets       fs.FS
+	assetPacks       []io.Closer
+	assetsMutex      sync.RWMutex
+	assetCache       *AssetCache
+	plugins          map[string]Plugin
+	loadedPlugins    []Plugin
+	frameHooks       []FrameHook
+	services         map[string]interface{}
+	servicesMutex    sync.RWMutex
+	listeners        map[string][]Listener
+	listenersMutex   sync.RWMutex
+	decoders         map[string]Decoder
+	decodersMutex    sync.RWMutex
+	localeState      localeState
+	storage          *Storage
+	userFS           *UserFS
+	eventMask        int32
+	input            *inputState
+	actions          *Actions
+	gestures         *gestureRecognizer
+	tickMutex        sync.Mutex
+	recorder         *recorder
+	replayer         *replayer
+	recordingClosers []io.Closer
+}
+
+// initCore fills the core with globally registered plugins and the ones
+// given in options
+func (core *runtimeCore) initCore(opts *options) error {
+	core.plugins = make(map[string]Plugin, len(registeredPlugins)+len(opts.plugins))
+	core.services = make(map[string]interface{})
+	core.listeners = make(map[string][]Listener)
+	core.input = newInputState()
+	core.actions = newActions(core.input)
+	core.gestures = newGestureRecognizer(core.Publish)
+	core.initDecoders()
+	for name, plugin := range registeredPlugins {
+		core.plugins[name] = plugin
+	}
+	for _, plugin := range opts.plugins {
+		name := plugin.GetName()
+		if registered, found := core.plugins[name]; found {
+			// Same plugin both registered and given in options
+			if reflect.TypeOf(plugin).Comparable() && registered == plugin {
+				continue
+			}
+			return fmt.Errorf("plugin %s already registered", name)
+		}
+		core.plugins[name] = plugin
+	}
+	return nil
+}
+
+func (core *runtimeCore) GetLogger() Logger {
+	return _logger
+}
+
+func (core *runtimeCore) GetPlugin(name string) (Plugin, bool) {
+	plugin, found := core.plugins[name]
+	return plugin, found
+}
+
+// sortPlugins returns plugins ordered so that each plugin comes after
+// its dependencies, independent plugins are ordered by name
+func (core *runtimeCore) sortPlugins() ([]Plugin, error) {
+	names := make([]string, 0, len(core.plugins))
+	for name := range core.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int, len(core.plugins))
+	sorted := make([]Plugin, 0, len(core.plugins))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					path = path[i:]
+					break
+				}
+			}
+			return fmt.Errorf("plugins dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		states[name] = visiting
+		plugin := core.plugins[name]
+		if dependent, ok := plugin.(DependentPlugin); ok {
+			for _, dependency := range dependent.Dependencies() {
+				if _, found := core.plugins[dependency]; !found {
+					return fmt.Errorf("plugin %s depends on %s which is not registered", name, dependency)
+				}
+				if err := visit(dependency, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		states[name] = visited
+		sorted = append(sorted, plugin)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
 	}
+	return sorted, nil
 }
 
-func initPlugins() {
-	for _, plugin := range plugins {
-		err := plugin.Init(_runtimeInstance)
+func (core *runtimeCore) initPlugins(runtime Runtime) {
+	sorted, err := core.sortPlugins()
+	if err != nil {
+		_logger.Error("Failed to resolve plugins dependencies", "error", err)
+		panic(err)
+	}
+	for _, plugin := range sorted {
+		err := plugin.Init(runtime)
 		if err != nil {
-			fmt.Printf("Failed to initialize plugin %s: %v\n", plugin.GetName(), err)
+			_logger.Error("Failed to initialize plugin", "plugin", plugin.GetName(), "error", err)
 			panic(err)
 		}
-		fmt.Printf("Plugin %s loaded\n", plugin.GetName())
+		core.loadedPlugins = append(core.loadedPlugins, plugin)
+		if frameHook, ok := plugin.(FrameHook); ok {
+			core.frameHooks = append(core.frameHooks, frameHook)
+		}
+		_logger.Info("Plugin loaded", "plugin", plugin.GetName())
+	}
+}
+
+func (core *runtimeCore) pausePlugins() {
+	for i := len(core.loadedPlugins) - 1; i >= 0; i-- {
+		if plugin, ok := core.loadedPlugins[i].(Pausable); ok {
+			plugin.OnPause()
+		}
+	}
+}
+
+func (core *runtimeCore) resumePlugins() {
+	for _, plugin := range core.loadedPlugins {
+		if plugin, ok := plugin.(Resumable); ok {
+			plugin.OnResume()
+		}
+	}
+}
+
+func (core *runtimeCore) resizePlugins(width, height int32) {
+	for _, plugin := range core.loadedPlugins {
+		if plugin, ok := plugin.(Resizable); ok {
+			plugin.OnResize(width, height)
+		}
+	}
+}
+
+func (core *runtimeCore) preRenderPlugins(elapsedTime time.Duration) {
+	for _, frameHook := range core.frameHooks {
+		frameHook.PreRender(elapsedTime)
+	}
+}
+
+func (core *runtimeCore) postRenderPlugins(elapsedTime time.Duration) {
+	for i := len(core.frameHooks) - 1; i >= 0; i-- {
+		core.frameHooks[i].PostRender(elapsedTime)
+	}
+}
+
+func (core *runtimeCore) preTickPlugins(elapsedTime time.Duration) {
+	for _, frameHook := range core.frameHooks {
+		frameHook.PreTick(elapsedTime)
+	}
+}
+
+func (core *runtimeCore) postTickPlugins(elapsedTime time.Duration) {
+	for i := len(core.frameHooks) - 1; i >= 0; i-- {
+		core.frameHooks[i].PostTick(elapsedTime)
 	}
 }
 
-// Global dispose
-func dispose() {
-	for _, plugin := range plugins {
+// Global dispose, plugins are released in reverse order of initialization
+func (core *runtimeCore) dispose() {
+	for i := len(core.loadedPlugins) - 1; i >= 0; i-- {
+		plugin := core.loadedPlugins[i]
 		plugin.Dispose()
-		fmt.Printf("Plugin %s released\n", plugin.GetName())
+		_logger.Info("Plugin released", "plugin", plugin.GetName())
+	}
+	core.loadedPlugins = nil
+	core.frameHooks = nil
+	core.clearServices()
+	core.closeAssetPacks()
+}
+
+// -------------------------------------------------------------------- //
+// Services
+// -------------------------------------------------------------------- //
+
+func (core *runtimeCore) RegisterService(name string, service interface{}) error {
+	core.servicesMutex.Lock()
+	defer core.servicesMutex.Unlock()
+	if _, found := core.services[name]; found {
+		return fmt.Errorf("service %s already registered", name)
+	}
+	core.services[name] = service
+	_logger.Debug("Service registered", "service", name)
+	return nil
+}
+
+func (core *runtimeCore) UnregisterService(name string) {
+	core.servicesMutex.Lock()
+	defer core.servicesMutex.Unlock()
+	delete(core.services, name)
+}
+
+func (core *runtimeCore) GetService(name string, target interface{}) error {
+	core.servicesMutex.RLock()
+	service, found := core.services[name]
+	core.servicesMutex.RUnlock()
+	if !found {
+		return fmt.Errorf("service %s not found", name)
+	}
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return fmt.Errorf("target of service %s must be a non-nil pointer", name)
+	}
+	serviceValue := reflect.ValueOf(service)
+	if !serviceValue.IsValid() {
+		targetValue.Elem().Set(reflect.Zero(targetValue.Elem().Type()))
+		return nil
 	}
+	if !serviceValue.Type().AssignableTo(targetValue.Elem().Type()) {
+		return fmt.Errorf("service %s of type %s is not assignable to %s", name, serviceValue.Type(), targetValue.Elem().Type())
+	}
+	targetValue.Elem().Set(serviceValue)
+	return nil
+}
+
+func (core *runtimeCore) clearServices() {
+	core.servicesMutex.Lock()
+	defer core.servicesMutex.Unlock()
+	core.services = make(map[string]interface{})
 }
 
 // -------------------------------------------------------------------- //
@@ -268,29 +720,215 @@ func (e KeyEvent) Channel() string {
 	return "key"
 }
 
-// Inner map of listeners
-var listeners = make(map[string][]Listener)
+// TextInputEvent is triggered when text is typed, Text contains the produced
+// characters independently of the keys used (layout, IME, soft keyboard)
+type TextInputEvent struct {
+	Text string
+}
+
+// Channel of TextInputEvent = "text"
+func (e TextInputEvent) Channel() string {
+	return "text"
+}
 
-func subscribe(channel string, listener Listener) {
-	if _, found := listeners[channel]; !found {
-		listeners[channel] = make([]Listener, 0, 10)
+// KeyboardType defines the layout of the virtual keyboard shown by ShowKeyboard()
+type KeyboardType int
+
+// KeyboardType constants
+const (
+	KeyboardText KeyboardType = iota
+	KeyboardNumber
+	KeyboardEmail
+	KeyboardPassword
+)
+
+func (k KeyboardType) String() string {
+	switch k {
+	case KeyboardNumber:
+		return "number"
+	case KeyboardEmail:
+		return "email"
+	case KeyboardPassword:
+		return "password"
+	default:
+		return "text"
+	}
+}
+
+// GamepadButton identifies a button of a gamepad using the standard layout
+type GamepadButton byte
+
+// GamepadAxis identifies an axis of a gamepad using the standard layout
+type GamepadAxis byte
+
+// GamepadButton values
+const (
+	// GamepadButtonNone for not available or not applicable
+	GamepadButtonNone GamepadButton = iota
+	// GamepadButtonA bottom face button
+	GamepadButtonA
+	// GamepadButtonB right face button
+	GamepadButtonB
+	// GamepadButtonX left face button
+	GamepadButtonX
+	// GamepadButtonY top face button
+	GamepadButtonY
+	// GamepadButtonBack back/select button
+	GamepadButtonBack
+	// GamepadButtonGuide guide/home button
+	GamepadButtonGuide
+	// GamepadButtonStart start button
+	GamepadButtonStart
+	// GamepadButtonLeftStick left stick press
+	GamepadButtonLeftStick
+	// GamepadButtonRightStick right stick press
+	GamepadButtonRightStick
+	// GamepadButtonLeftShoulder left shoulder button
+	GamepadButtonLeftShoulder
+	// GamepadButtonRightShoulder right shoulder button
+	GamepadButtonRightShoulder
+	// GamepadButtonDpadUp directional pad up
+	GamepadButtonDpadUp
+	// GamepadButtonDpadDown directional pad down
+	GamepadButtonDpadDown
+	// GamepadButtonDpadLeft directional pad left
+	GamepadButtonDpadLeft
+	// GamepadButtonDpadRight directional pad right
+	GamepadButtonDpadRight
+)
+
+var gamepadButtonNames = []string{"None", "A", "B", "X", "Y", "Back", "Guide", "Start", "LeftStick",
+	"RightStick", "LeftShoulder", "RightShoulder", "DpadUp", "DpadDown", "DpadLeft", "DpadRight"}
+
+// String is Stringer implementation of GamepadButton
+func (b GamepadButton) String() string {
+	if int(b) < len(gamepadButtonNames) {
+		return gamepadButtonNames[b]
+	}
+	return "unknown"
+}
+
+// GamepadAxis values
+const (
+	// GamepadAxisNone for not available or not applicable
+	GamepadAxisNone GamepadAxis = iota
+	// GamepadAxisLeftX left stick horizontal axis
+	GamepadAxisLeftX
+	// GamepadAxisLeftY left stick vertical axis
+	GamepadAxisLeftY
+	// GamepadAxisRightX right stick horizontal axis
+	GamepadAxisRightX
+	// GamepadAxisRightY right stick vertical axis
+	GamepadAxisRightY
+	// GamepadAxisTriggerLeft left trigger
+	GamepadAxisTriggerLeft
+	// GamepadAxisTriggerRight right trigger
+	GamepadAxisTriggerRight
+)
+
+var gamepadAxisNames = []string{"None", "LeftX", "LeftY", "RightX", "RightY", "TriggerLeft", "TriggerRight"}
+
+// String is Stringer implementation of GamepadAxis
+func (a GamepadAxis) String() string {
+	if int(a) < len(gamepadAxisNames) {
+		return gamepadAxisNames[a]
 	}
-	listeners[channel] = append(listeners[channel], listener)
+	return "unknown"
+}
+
+// GamepadEvent is triggered on gamepad buttons (TypeDown/TypeUp) and axes (TypeMove)
+// changes, Gamepad identifies the device. Axes values are in [-1, 1] for sticks
+// (down and right are positive) and in [0, 1] for triggers. Gamepads are available
+// on desktop and browser only.
+type GamepadEvent struct {
+	Gamepad int32
+	Button  GamepadButton
+	Axis    GamepadAxis
+	Value   float32
+	Type    Type
 }
 
-func unsubscribe(channel string, listener Listener) {
-	if all, found := listeners[channel]; found {
+// Channel of GamepadEvent = "gamepad"
+func (e GamepadEvent) Channel() string {
+	return "gamepad"
+}
+
+// SetEventMask enables/disables events receivers at runtime, mask replaces Settings.EventMask
+func (core *runtimeCore) SetEventMask(mask EventMask) {
+	atomic.StoreInt32(&core.eventMask, int32(mask&AllEventsEnabled))
+	_logger.Debug("Event mask changed", "mask", fmt.Sprintf("%#x", int(mask)))
+}
+
+// getEventMask returns the current event mask
+func (core *runtimeCore) getEventMask() EventMask {
+	return EventMask(atomic.LoadInt32(&core.eventMask))
+}
+
+// isEventEnabled indicates if one of the events of mask is enabled
+func (core *runtimeCore) isEventEnabled(mask EventMask) bool {
+	return core.getEventMask()&mask != 0
+}
+
+// isTouchEventEnabled indicates if touches of eventType are enabled, the mouse bits
+// enable touches too as touches are published as MouseEvent
+func (core *runtimeCore) isTouchEventEnabled(eventType Type) bool {
+	if eventType == TypeMove {
+		return core.isEventEnabled(TouchEventEnabled | MouseMotionEventEnabled)
+	}
+	return core.isEventEnabled(TouchEventEnabled | MouseButtonEventEnabled)
+}
+
+func (core *runtimeCore) Subscribe(channel string, listener Listener) {
+	core.listenersMutex.Lock()
+	defer core.listenersMutex.Unlock()
+	if _, found := core.listeners[channel]; !found {
+		core.listeners[channel] = make([]Listener, 0, 10)
+	}
+	core.listeners[channel] = append(core.listeners[channel], listener)
+}
+
+func (core *runtimeCore) Unsubscribe(channel string, listener Listener) {
+	core.listenersMutex.Lock()
+	defer core.listenersMutex.Unlock()
+	if all, found := core.listeners[channel]; found {
 		for i, l := range all {
 			if reflect.ValueOf(l).Pointer() == reflect.ValueOf(listener).Pointer() {
-				listeners[channel] = append(listeners[channel][:i], listeners[channel][i+1:]...)
+				// Copy on write as the list can be used by Publish()
+				core.listeners[channel] = append(append(make([]Listener, 0, len(all)), all[:i]...), all[i+1:]...)
 				break
 			}
 		}
 	}
 }
 
-func publish(event Event) {
-	if list, found := listeners[event.Channel()]; found {
+func (core *runtimeCore) Publish(event Event) {
+	// Live input events are replaced by the recorded ones during replay
+	if core.replayer != nil && core.replayer.isReplaying() && recordedEventName(event) != "" {
+		return
+	}
+	core.publish(event)
+}
+
+// publish records event and sends it to listeners
+func (core *runtimeCore) publish(event Event) {
+	// Input state and gestures are updated synchronously to keep events order
+	now := core.now()
+	core.tickMutex.Lock()
+	if core.recorder != nil {
+		if err := core.recorder.recordEvent(event, now); err != nil {
+			_logger.Error("Failed to record event", "error", err)
+			core.recorder = nil
+		}
+	}
+	core.input.update(event)
+	core.tickMutex.Unlock()
+	if core.isEventEnabled(GestureEventEnabled) {
+		core.gestures.update(event, now)
+	}
+	core.listenersMutex.RLock()
+	list, found := core.listeners[event.Channel()]
+	core.listenersMutex.RUnlock()
+	if found {
 		switch event.(type) {
 		case ResizeEvent:
 			for _, listener := range list {
@@ -494,3 +1132,140 @@ func (k KeyCode) IsModifier() bool {
 func (k KeyCode) IsCompose() bool {
 	return k == KeyCodeCompose
 }
+
+// Names of KeyCode values, used by String() and to parse actions bindings
+var keyCodeNames = map[KeyCode]string{
+	KeyCodeUnknown:            "Unknown",
+	KeyCodeA:                  "A",
+	KeyCodeB:                  "B",
+	KeyCodeC:                  "C",
+	KeyCodeD:                  "D",
+	KeyCodeE:                  "E",
+	KeyCodeF:                  "F",
+	KeyCodeG:                  "G",
+	KeyCodeH:                  "H",
+	KeyCodeI:                  "I",
+	KeyCodeJ:                  "J",
+	KeyCodeK:                  "K",
+	KeyCodeL:                  "L",
+	KeyCodeM:                  "M",
+	KeyCodeN:                  "N",
+	KeyCodeO:                  "O",
+	KeyCodeP:                  "P",
+	KeyCodeQ:                  "Q",
+	KeyCodeR:                  "R",
+	KeyCodeS:                  "S",
+	KeyCodeT:                  "T",
+	KeyCodeU:                  "U",
+	KeyCodeV:                  "V",
+	KeyCodeW:                  "W",
+	KeyCodeX:                  "X",
+	KeyCodeY:                  "Y",
+	KeyCodeZ:                  "Z",
+	KeyCode1:                  "1",
+	KeyCode2:                  "2",
+	KeyCode3:                  "3",
+	KeyCode4:                  "4",
+	KeyCode5:                  "5",
+	KeyCode6:                  "6",
+	KeyCode7:                  "7",
+	KeyCode8:                  "8",
+	KeyCode9:                  "9",
+	KeyCode0:                  "0",
+	KeyCodeReturnEnter:        "ReturnEnter",
+	KeyCodeTab:                "Tab",
+	KeyCodeSpacebar:           "Spacebar",
+	KeyCodeHyphenMinus:        "HyphenMinus",
+	KeyCodeEqualSign:          "EqualSign",
+	KeyCodeLeftSquareBracket:  "LeftSquareBracket",
+	KeyCodeRightSquareBracket: "RightSquareBracket",
+	KeyCodeBackslash:          "Backslash",
+	KeyCodeSemicolon:          "Semicolon",
+	KeyCodeApostrophe:         "Apostrophe",
+	KeyCodeGraveAccent:        "GraveAccent",
+	KeyCodeComma:              "Comma",
+	KeyCodeFullStop:           "FullStop",
+	KeyCodeSlash:              "Slash",
+	KeyCodeKeypadSlash:        "KeypadSlash",
+	KeyCodeKeypadAsterisk:     "KeypadAsterisk",
+	KeyCodeKeypadHyphenMinus:  "KeypadHyphenMinus",
+	KeyCodeKeypadPlusSign:     "KeypadPlusSign",
+	KeyCodeKeypadEnter:        "KeypadEnter",
+	KeyCodeKeypad1:            "Keypad1",
+	KeyCodeKeypad2:            "Keypad2",
+	KeyCodeKeypad3:            "Keypad3",
+	KeyCodeKeypad4:            "Keypad4",
+	KeyCodeKeypad5:            "Keypad5",
+	KeyCodeKeypad6:            "Keypad6",
+	KeyCodeKeypad7:            "Keypad7",
+	KeyCodeKeypad8:            "Keypad8",
+	KeyCodeKeypad9:            "Keypad9",
+	KeyCodeKeypad0:            "Keypad0",
+	KeyCodeKeypadFullStop:     "KeypadFullStop",
+	KeyCodeKeypadEqualSign:    "KeypadEqualSign",
+	KeyCodeAt:                 "At",
+	KeyCodeGreaterThan:        "GreaterThan",
+	KeyCodeLesserThan:         "LesserThan",
+	KeyCodeDollar:             "Dollar",
+	KeyCodeColon:              "Colon",
+	KeyCodeLeftParenthesis:    "LeftParenthesis",
+	KeyCodeLRightParenthesis:  "LRightParenthesis",
+	KeyCodeAmpersand:          "Ampersand",
+	KeyCodeHash:               "Hash",
+	KeyDoubleQuote:            "DoubleQuote",
+	KeyQuote:                  "Quote",
+	KeyParapgrah:              "Parapgrah",
+	KeyExclamationMark:        "ExclamationMark",
+	KeyUnderscore:             "Underscore",
+	KeyQuestionMark:           "QuestionMark",
+	KeyPercent:                "Percent",
+	KeyDegree:                 "Degree",
+	KeyCodeEscape:             "Escape",
+	KeyCodeCapsLock:           "CapsLock",
+	KeyCodeDeleteBackspace:    "DeleteBackspace",
+	KeyCodePause:              "Pause",
+	KeyCodeInsert:             "Insert",
+	KeyCodeHome:               "Home",
+	KeyCodePageUp:             "PageUp",
+	KeyCodeDeleteForward:      "DeleteForward",
+	KeyCodeEnd:                "End",
+	KeyCodePageDown:           "PageDown",
+	KeyCodeRightArrow:         "RightArrow",
+	KeyCodeLeftArrow:          "LeftArrow",
+	KeyCodeDownArrow:          "DownArrow",
+	KeyCodeUpArrow:            "UpArrow",
+	KeyCodeKeypadNumLock:      "KeypadNumLock",
+	KeyCodeHelp:               "Help",
+	KeyCodeMute:               "Mute",
+	KeyCodeVolumeUp:           "VolumeUp",
+	KeyCodeVolumeDown:         "VolumeDown",
+	KeyCodeF1:                 "F1",
+	KeyCodeF2:                 "F2",
+	KeyCodeF3:                 "F3",
+	KeyCodeF4:                 "F4",
+	KeyCodeF5:                 "F5",
+	KeyCodeF6:                 "F6",
+	KeyCodeF7:                 "F7",
+	KeyCodeF8:                 "F8",
+	KeyCodeF9:                 "F9",
+	KeyCodeF10:                "F10",
+	KeyCodeF11:                "F11",
+	KeyCodeF12:                "F12",
+	KeyCodeLeftControl:        "LeftControl",
+	KeyCodeLeftShift:          "LeftShift",
+	KeyCodeLeftAlt:            "LeftAlt",
+	KeyCodeLeftGUI:            "LeftGUI",
+	KeyCodeRightControl:       "RightControl",
+	KeyCodeRightShift:         "RightShift",
+	KeyCodeRightAlt:           "RightAlt",
+	KeyCodeRightGUI:           "RightGUI",
+	KeyCodeCompose:            "Compose",
+}
+
+// String is Stringer implementation of KeyCode
+func (k KeyCode) String() string {
+	if name, found := keyCodeNames[k]; found {
+		return name
+	}
+	return "unknown"
+}
@@ -7,67 +7,29 @@ package tge
 
 import (
 	fmt "fmt"
+	fs "io/fs"
 	math "math"
 	js "syscall/js"
 	time "time"
+	utf8 "unicode/utf8"
 )
 
-func init() {
-	_runtimeInstance = &browserRuntime{}
-}
-
 // -------------------------------------------------------------------- //
 // Runtime implementation
 // -------------------------------------------------------------------- //
 type browserRuntime struct {
+	runtimeCore
 	app       App
 	ticker    *time.Ticker
 	canvas    *js.Value
+	keyboard  *js.Value
 	jsTge     *js.Value
 	settings  Settings
 	isPaused  bool
 	isStopped bool
 	done      chan bool
-}
-
-func (runtime *browserRuntime) GetAsset(p string) ([]byte, error) {
-	var data []byte
-	var err error
-	var doneState = make(chan bool)
-
-	onLoadAssetCallback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		if args[0] != js.Null() {
-			err = fmt.Errorf(args[1].String())
-			doneState <- false
-		} else {
-			doneState <- true
-		}
-		return false
-	})
-
-	onGetAssetSizeCallback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		if args[1] != js.Null() {
-			err = fmt.Errorf(args[1].String())
-			doneState <- false
-		} else if size := args[0].Int(); size > 0 {
-			data = make([]byte, size)
-			jsData := js.TypedArrayOf(data)
-			defer jsData.Release()
-			runtime.jsTge.Call("loadAsset", p, jsData, onLoadAssetCallback)
-		} else {
-			err = fmt.Errorf("empty asset")
-			doneState <- false
-		}
-		return false
-	})
-	defer onLoadAssetCallback.Release()
-	defer onGetAssetSizeCallback.Release()
-
-	runtime.jsTge.Call("getAssetSize", p, onGetAssetSizeCallback)
-
-	<-doneState
-
-	return data, err
+	gamepads  map[int32]*browserGamepad
+	touches   map[int]Button
 }
 
 func (runtime *browserRuntime) GetHost() interface{} {
@@ -77,101 +39,150 @@ func (runtime *browserRuntime) GetHost() interface{} {
 
 func (runtime *browserRuntime) GetRenderer() interface{} {
 	glContext := runtime.canvas.Call("getContext", "webgl2")
-	if glContext == js.Undefined() || glContext == js.Null() {
-		fmt.Println("WARNING: No WebGL2 support")
+	if glContext.IsUndefined() || glContext.IsNull() {
+		_logger.Warn("No WebGL2 support, fallback to WebGL")
 		glContext = runtime.canvas.Call("getContext", "webgl")
 	}
-	if glContext == js.Undefined() || glContext == js.Null() {
-		fmt.Println("WARNING: No WebGL support")
+	if glContext.IsUndefined() || glContext.IsNull() {
+		_logger.Warn("No WebGL support, fallback to experimental WebGL")
 		glContext = runtime.canvas.Call("getContext", "experimental-webgl")
 	}
-	if glContext == js.Undefined() || glContext == js.Null() {
+	if glContext.IsUndefined() || glContext.IsNull() {
 		err := fmt.Errorf("No WebGL support found in brower")
-		fmt.Println(err)
+		_logger.Error("Failed to get WebGL context", "error", err)
 		panic(err)
 	}
 	return &glContext
 }
 
 func (runtime *browserRuntime) GetSettings() Settings {
-	return runtime.settings
+	settings := runtime.settings.clone()
+	settings.EventMask = runtime.getEventMask()
+	settings.Actions = runtime.actions.getBindings()
+	return settings
 }
 
-func (runtime *browserRuntime) Subscribe(channel string, listener Listener) {
-	subscribe(channel, listener)
+func (runtime *browserRuntime) ShowKeyboard(keyboardType KeyboardType) {
+	runtime.jsTge.Call("showKeyboard", keyboardType.String())
 }
 
-func (runtime *browserRuntime) Unsubscribe(channel string, listener Listener) {
-	unsubscribe(channel, listener)
-}
-
-func (runtime *browserRuntime) Publish(event Event) {
-	publish(event)
+func (runtime *browserRuntime) HideKeyboard() {
+	runtime.jsTge.Call("hideKeyboard")
 }
 
 func (runtime *browserRuntime) Stop() {
 	if !runtime.isPaused {
 		runtime.isPaused = true
 		runtime.app.OnPause()
+		runtime.pausePlugins()
 	}
 	runtime.isStopped = true
 	runtime.app.OnStop()
-	dispose()
+	runtime.dispose()
+	runtime.disposeRecording()
 	runtime.app.OnDispose()
 }
 
 // Run main entry point of runtime
-func Run(app App) error {
+func Run(app App, options ...Option) error {
 	// -------------------------------------------------------------------- //
 	// Create
 	// -------------------------------------------------------------------- //
-	settings := defaultSettings
-	err := app.OnCreate(&settings)
+	opts := newOptions(options)
+	jsTge := js.Global().Get("tge")
+	var settingsAssets fs.FS = &browserAssetFS{jsTge: &jsTge}
+	if opts.assets != nil {
+		settingsAssets = opts.assets
+	}
+	settings, userFS, err := loadSettings(settingsAssets, querySettings())
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to load settings", "error", err)
+		panic(err)
+	}
+	// UserFS stays located with the name known before OnCreate()
+	name := settings.Name
+	err = app.OnCreate(&settings)
+	if err != nil {
+		_logger.Error("Failed to create App", "error", err)
+		panic(err)
+	}
+	if settings.Name != name {
+		_logger.Warn("Name set by App is not used to locate the user file system", "name", name)
+	}
+	userFS.setQuota(settings.UserFSQuota)
+	_logger.setDefaultLevel(settings.LogLevel)
+	if err = settings.Validate(); err != nil {
+		_logger.Error("Invalid settings", "error", err)
 		panic(err)
 	}
 
 	// -------------------------------------------------------------------- //
 	// Init
 	// -------------------------------------------------------------------- //
-	jsTge := js.Global().Get("tge")
 	if settings.Fullscreen {
 		jsTge.Call("setFullscreen", settings.Fullscreen)
 	} else {
+		settings.clampWindow(0, 0, js.Global().Get("innerWidth").Int(), js.Global().Get("innerHeight").Int())
 		jsTge.Call("resize", settings.Width, settings.Height)
 	}
 
 	canvas := jsTge.Call("init")
+	keyboard := jsTge.Call("getKeyboard")
 
 	// Instanciate Runtime
-	browserRuntime := _runtimeInstance.(*browserRuntime)
+	browserRuntime := &browserRuntime{}
+	if err = browserRuntime.initCore(opts); err != nil {
+		_logger.Error("Failed to create Runtime", "error", err)
+		panic(err)
+	}
 	browserRuntime.app = app
 	browserRuntime.canvas = &canvas
+	browserRuntime.keyboard = &keyboard
 	browserRuntime.jsTge = &jsTge
+	browserRuntime.storage = newStorage(newStorageBackend(settings))
+	browserRuntime.userFS = userFS
+	browserRuntime.initLocale(settings, devicePixelRatio())
+	if opts.assets != nil {
+		browserRuntime.initAssets(assetFS{opts.assets}, settings)
+	} else {
+		browserRuntime.initAssets(assetFS{&browserAssetFS{jsTge: &jsTge}}, settings)
+	}
 	browserRuntime.settings = settings
+	browserRuntime.SetEventMask(settings.EventMask)
+	browserRuntime.initActions(settings, userFS)
+	browserRuntime.gamepads = make(map[int32]*browserGamepad)
+	browserRuntime.touches = make(map[int]Button)
 	browserRuntime.isPaused = true
 	browserRuntime.isStopped = true
 	browserRuntime.done = make(chan bool)
 
+	// Record or replay input events
+	if err = browserRuntime.initRecording(opts, settings); err != nil {
+		_logger.Error("Failed to start recording", "error", err)
+		panic(err)
+	}
+
 	// Init plugins
-	initPlugins()
+	browserRuntime.initPlugins(browserRuntime)
 
 	// Start App
 	err = app.OnStart(browserRuntime)
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to start App", "error", err)
 		panic(err)
 	}
 	browserRuntime.isStopped = false
 
 	// Resume App
+	browserRuntime.resumePlugins()
 	app.OnResume()
 	browserRuntime.isPaused = false
 
 	// Resize App
-	publish(ResizeEvent{int32(browserRuntime.canvas.Get("clientWidth").Int()),
-		int32(browserRuntime.canvas.Get("clientHeight").Int())})
+	w := int32(browserRuntime.canvas.Get("clientWidth").Int())
+	h := int32(browserRuntime.canvas.Get("clientHeight").Int())
+	browserRuntime.resizePlugins(w, h)
+	browserRuntime.Publish(ResizeEvent{w, h})
 
 	// -------------------------------------------------------------------- //
 	// Ticker Loop
@@ -182,7 +193,10 @@ func Run(app App) error {
 		for !browserRuntime.isStopped {
 			if !browserRuntime.isPaused {
 				now := time.Now()
+				elapsedTpsTime = browserRuntime.beginTick(elapsedTpsTime)
+				browserRuntime.preTickPlugins(elapsedTpsTime)
 				app.OnTick(elapsedTpsTime, syncChan)
+				browserRuntime.postTickPlugins(elapsedTpsTime)
 				elapsedTpsTime = time.Since(now)
 			}
 		}
@@ -198,7 +212,9 @@ func Run(app App) error {
 			w := int32(browserRuntime.canvas.Get("clientWidth").Int())
 			h := int32(browserRuntime.canvas.Get("clientHeight").Int())
 			jsTge.Call("resize", w, h)
-			publish(ResizeEvent{
+			browserRuntime.setPixelDensity(devicePixelRatio())
+			browserRuntime.resizePlugins(w, h)
+			browserRuntime.Publish(ResizeEvent{
 				Width:  w,
 				Height: h,
 			})
@@ -210,10 +226,16 @@ func Run(app App) error {
 
 	// Focus
 	blurEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// Focus given to the virtual keyboard input is not a pause
+		if args[0].Get("relatedTarget").Equal(*browserRuntime.keyboard) {
+			return false
+		}
 		if !browserRuntime.isStopped && !browserRuntime.isPaused {
 			go func() {
+				browserRuntime.releaseInputs()
 				browserRuntime.isPaused = true
 				browserRuntime.app.OnPause()
+				browserRuntime.pausePlugins()
 			}()
 		}
 		return false
@@ -225,6 +247,7 @@ func Run(app App) error {
 		if !browserRuntime.isStopped && browserRuntime.isPaused {
 			//Called in go routine in case of asset loading in resume (blocking)
 			go func() {
+				browserRuntime.resumePlugins()
 				browserRuntime.app.OnResume()
 				browserRuntime.isPaused = false
 			}()
@@ -244,224 +267,137 @@ func Run(app App) error {
 	defer beforeunloadEvtCb.Release()
 	js.Global().Call("addEventListener", "beforeunload", beforeunloadEvtCb)
 
-	// MouseButtonEvent
-	if (settings.EventMask & MouseButtonEventEnabled) != 0 {
-		mouseDownEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				button := ButtonNone
-				switch event.Get("button").Int() {
-				case 0:
-					button = ButtonLeft
-				case 1:
-					button = ButtonMiddle
-				case 2:
-					button = ButtonRight
-				}
-				publish(MouseEvent{
-					X:      int32(event.Get("offsetX").Int()),
-					Y:      int32(event.Get("offsetY").Int()),
-					Button: button,
-					Type:   TypeDown,
-				})
-			}
-			return false
-		})
-		defer mouseDownEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "mousedown", mouseDownEvtCb)
-
-		touchDownEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				event.Call("preventDefault")
-				touchList := event.Get("touches")
-				touchListLen := touchList.Get("length").Int()
-				for i := 0; i < touchListLen; i++ {
-					button := ButtonNone
-					switch i {
-					case 0:
-						button = TouchFirst
-					case 1:
-						button = TouchSecond
-					case 2:
-						button = TouchThird
-					}
-					touch := touchList.Index(i)
-					publish(MouseEvent{
-						X:      int32(touch.Get("clientX").Int()),
-						Y:      int32(touch.Get("clientY").Int()),
-						Button: button,
-						Type:   TypeDown,
-					})
-				}
-			}
-			return false
-		})
-		defer touchDownEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "touchstart", touchDownEvtCb)
-
-		mouseUpEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				button := ButtonNone
-				switch event.Get("button").Int() {
-				case 0:
-					button = ButtonLeft
-				case 1:
-					button = ButtonMiddle
-				case 2:
-					button = ButtonRight
-				}
-				publish(MouseEvent{
-					X:      int32(event.Get("offsetX").Int()),
-					Y:      int32(event.Get("offsetY").Int()),
-					Button: button,
-					Type:   TypeUp,
-				})
-			}
-			return false
-		})
-		defer mouseUpEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "mouseup", mouseUpEvtCb)
-
-		touchUpEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				event.Call("preventDefault")
-				touchList := event.Get("changedTouches")
-				touchListLen := touchList.Get("length").Int()
-				for i := 0; i < touchListLen; i++ {
-					button := ButtonNone
-					switch i {
-					case 0:
-						button = TouchFirst
-					case 1:
-						button = TouchSecond
-					case 2:
-						button = TouchThird
-					}
-					touch := touchList.Index(i)
-					publish(MouseEvent{
-						X:      int32(touch.Get("clientX").Int()),
-						Y:      int32(touch.Get("clientY").Int()),
-						Button: button,
-						Type:   TypeUp,
-					})
-				}
-			}
-			return false
-		})
-		defer touchUpEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "touchend", touchUpEvtCb)
-	}
+	// MouseEvent from Pointer Events, touches use the same buttons sequence as mobile
+	pointerDownEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !browserRuntime.isPaused {
+			event := args[0]
+			// Moves and up are received even outside of the canvas
+			browserRuntime.canvas.Call("setPointerCapture", event.Get("pointerId"))
+			browserRuntime.publishPointer(event, TypeDown)
+		}
+		return false
+	})
+	defer pointerDownEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "pointerdown", pointerDownEvtCb)
 
-	// MouseMotionEventEnabled
-	if (settings.EventMask & MouseMotionEventEnabled) != 0 {
-		mouseMoveEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				publish(MouseEvent{
-					X:      int32(event.Get("clientX").Int()),
-					Y:      int32(event.Get("clientY").Int()),
-					Button: ButtonNone,
-					Type:   TypeMove,
-				})
-			}
-			return false
-		})
-		defer mouseMoveEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "mousemove", mouseMoveEvtCb)
+	pointerMoveEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !browserRuntime.isPaused {
+			browserRuntime.publishPointer(args[0], TypeMove)
+		}
+		return false
+	})
+	defer pointerMoveEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "pointermove", pointerMoveEvtCb)
 
-		touchMoveEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				event.Call("preventDefault")
-				touchList := event.Get("touches")
-				touchListLen := touchList.Get("length").Int()
-				for i := 0; i < touchListLen; i++ {
-					button := ButtonNone
-					switch i {
-					case 0:
-						button = TouchFirst
-					case 1:
-						button = TouchSecond
-					case 2:
-						button = TouchThird
-					}
-					touch := touchList.Index(i)
-					publish(MouseEvent{
-						X:      int32(touch.Get("clientX").Int()),
-						Y:      int32(touch.Get("clientY").Int()),
-						Button: button,
-						Type:   TypeMove,
-					})
-				}
-			}
-			return false
-		})
-		defer touchMoveEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "touchmove", touchMoveEvtCb)
-	}
+	pointerUpEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// Up is always handled to release touches
+		if !browserRuntime.isStopped {
+			browserRuntime.publishPointer(args[0], TypeUp)
+		}
+		return false
+	})
+	defer pointerUpEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "pointerup", pointerUpEvtCb)
+	browserRuntime.canvas.Call("addEventListener", "pointercancel", pointerUpEvtCb)
 
 	// ScrollEvent
-	if (settings.EventMask & ScrollEventEnabled) != 0 {
-		wheelEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
-				event.Call("preventDefault")
-				x := float64(event.Get("deltaX").Int())
-				y := float64(event.Get("deltaY").Int())
-				if x != 0 {
-					x = x / math.Abs(x)
-				}
-				if y != 0 {
-					y = y / math.Abs(y)
-				}
-				publish(ScrollEvent{
-					X: int32(x),
-					Y: -int32(y),
-				})
+	wheelEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !browserRuntime.isPaused && browserRuntime.isEventEnabled(ScrollEventEnabled) {
+			event := args[0]
+			event.Call("preventDefault")
+			x := float64(event.Get("deltaX").Int())
+			y := float64(event.Get("deltaY").Int())
+			if x != 0 {
+				x = x / math.Abs(x)
 			}
-			return false
-		})
-		defer wheelEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "wheel", wheelEvtCb)
-	}
+			if y != 0 {
+				y = y / math.Abs(y)
+			}
+			browserRuntime.Publish(ScrollEvent{
+				X: int32(x),
+				Y: -int32(y),
+			})
+		}
+		return false
+	})
+	defer wheelEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "wheel", wheelEvtCb)
 
-	// KeyEvent
-	if (settings.EventMask & KeyEventEnabled) != 0 {
-		keyDownEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
+	// KeyEvent and TextInputEvent
+	keyDownEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !browserRuntime.isPaused {
+			event := args[0]
+			keyCode := event.Get("key").String()
+			if browserRuntime.isEventEnabled(KeyEventEnabled) {
 				event.Call("preventDefault")
-				keyCode := event.Get("key").String()
-				publish(KeyEvent{
+				browserRuntime.Publish(KeyEvent{
 					Key:   keyMap[keyCode],
 					Value: keyCode,
 					Type:  TypeDown,
 				})
 			}
-			return false
-		})
-		defer keyDownEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "keydown", keyDownEvtCb)
-
-		keyUpEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			if !browserRuntime.isStopped && !browserRuntime.isPaused {
-				event := args[0]
+			// Printable keys produce a single character, shortcuts are ignored
+			if browserRuntime.isEventEnabled(TextInputEventEnabled) && utf8.RuneCountInString(keyCode) == 1 &&
+				!event.Get("ctrlKey").Bool() && !event.Get("metaKey").Bool() {
 				event.Call("preventDefault")
-				keyCode := event.Get("key").String()
-				publish(KeyEvent{
-					Key:   keyMap[keyCode],
-					Value: keyCode,
-					Type:  TypeUp,
-				})
+				browserRuntime.Publish(TextInputEvent{Text: keyCode})
 			}
-			return false
-		})
-		defer keyUpEvtCb.Release()
-		browserRuntime.canvas.Call("addEventListener", "keyup", keyUpEvtCb)
-	}
+		}
+		return false
+	})
+	defer keyDownEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "keydown", keyDownEvtCb)
+
+	keyUpEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !browserRuntime.isPaused && browserRuntime.isEventEnabled(KeyEventEnabled) {
+			event := args[0]
+			event.Call("preventDefault")
+			keyCode := event.Get("key").String()
+			browserRuntime.Publish(KeyEvent{
+				Key:   keyMap[keyCode],
+				Value: keyCode,
+				Type:  TypeUp,
+			})
+		}
+		return false
+	})
+	defer keyUpEvtCb.Release()
+	browserRuntime.canvas.Call("addEventListener", "keyup", keyUpEvtCb)
+
+	// Virtual keyboard, text is read from the hidden input once composition is done
+	keyboardInputEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && !args[0].Get("isComposing").Truthy() {
+			text := browserRuntime.keyboard.Get("value").String()
+			browserRuntime.keyboard.Set("value", "")
+			if text != "" && browserRuntime.isEventEnabled(TextInputEventEnabled) {
+				browserRuntime.Publish(TextInputEvent{Text: text})
+			}
+		}
+		return false
+	})
+	defer keyboardInputEvtCb.Release()
+	browserRuntime.keyboard.Call("addEventListener", "input", keyboardInputEvtCb)
+	browserRuntime.keyboard.Call("addEventListener", "compositionend", keyboardInputEvtCb)
+
+	keyboardKeyEvtCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if !browserRuntime.isStopped && browserRuntime.isEventEnabled(KeyEventEnabled) {
+			event := args[0]
+			keyCode := event.Get("key").String()
+			eventType := TypeDown
+			if event.Get("type").String() == "keyup" {
+				eventType = TypeUp
+			}
+			browserRuntime.Publish(KeyEvent{
+				Key:   keyMap[keyCode],
+				Value: keyCode,
+				Type:  eventType,
+			})
+		}
+		return false
+	})
+	defer keyboardKeyEvtCb.Release()
+	browserRuntime.keyboard.Call("addEventListener", "keydown", keyboardKeyEvtCb)
+	browserRuntime.keyboard.Call("addEventListener", "keyup", keyboardKeyEvtCb)
 
 	// -------------------------------------------------------------------- //
 	// Render Loop
@@ -471,7 +407,12 @@ func Run(app App) error {
 	renderFrame = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		if !browserRuntime.isPaused {
 			now := time.Now()
+			if browserRuntime.isEventEnabled(GamepadEventEnabled) {
+				browserRuntime.pollGamepads()
+			}
+			browserRuntime.preRenderPlugins(elapsedFpsTime)
 			app.OnRender(elapsedFpsTime, syncChan)
+			browserRuntime.postRenderPlugins(elapsedFpsTime)
 			elapsedFpsTime = time.Since(now)
 		}
 		if !browserRuntime.isStopped {
@@ -494,6 +435,187 @@ func Run(app App) error {
 	return nil
 }
 
+// -------------------------------------------------------------------- //
+// Pointers
+// -------------------------------------------------------------------- //
+
+// Mouse buttons of Pointer Events, indexed by the button property
+var pointerButtons = []struct {
+	button Button
+	mask   int
+}{
+	{ButtonLeft, 1},
+	{ButtonMiddle, 4},
+	{ButtonRight, 2},
+}
+
+// publishPointer publishes a Pointer Event as MouseEvent, touches (and pens) are bound to
+// TouchFirst, TouchSecond and TouchThird in order of arrival like on mobile
+func (runtime *browserRuntime) publishPointer(event js.Value, eventType Type) {
+	x, y := runtime.pointerPosition(event)
+	if pointerType := event.Get("pointerType").String(); pointerType == "touch" || pointerType == "pen" {
+		id := event.Get("pointerId").Int()
+		button, found := runtime.touches[id]
+		switch {
+		case eventType == TypeDown && !found:
+			if button = runtime.freeTouch(); button == ButtonNone {
+				// More than 3 fingers
+				return
+			}
+			runtime.touches[id] = button
+		case !found:
+			return
+		case eventType == TypeUp:
+			delete(runtime.touches, id)
+		}
+		if runtime.isTouchEventEnabled(eventType) {
+			runtime.Publish(MouseEvent{X: x, Y: y, Button: button, Type: eventType})
+		}
+		return
+	}
+
+	// Mouse, a button pressed or released while another one is down is sent as a move
+	index := event.Get("button").Int()
+	if eventType == TypeMove && index >= 0 && index < len(pointerButtons) {
+		eventType = TypeUp
+		if event.Get("buttons").Int()&pointerButtons[index].mask != 0 {
+			eventType = TypeDown
+		}
+	}
+	switch eventType {
+	case TypeMove:
+		if runtime.isEventEnabled(MouseMotionEventEnabled) {
+			runtime.Publish(MouseEvent{X: x, Y: y, Button: ButtonNone, Type: TypeMove})
+		}
+	default:
+		button := ButtonNone
+		if index >= 0 && index < len(pointerButtons) {
+			button = pointerButtons[index].button
+		}
+		if runtime.isEventEnabled(MouseButtonEventEnabled) {
+			runtime.Publish(MouseEvent{X: x, Y: y, Button: button, Type: eventType})
+		}
+	}
+}
+
+// pointerPosition returns the position of a Pointer Event relative to the canvas and
+// scaled to the size of its drawing buffer
+func (runtime *browserRuntime) pointerPosition(event js.Value) (int32, int32) {
+	rect := runtime.canvas.Call("getBoundingClientRect")
+	x := event.Get("clientX").Float() - rect.Get("left").Float()
+	y := event.Get("clientY").Float() - rect.Get("top").Float()
+	if width := rect.Get("width").Float(); width > 0 {
+		x = x * runtime.canvas.Get("width").Float() / width
+	}
+	if height := rect.Get("height").Float(); height > 0 {
+		y = y * runtime.canvas.Get("height").Float() / height
+	}
+	return int32(math.Floor(x)), int32(math.Floor(y))
+}
+
+// freeTouch returns the first touch button not bound to a pointer, ButtonNone if
+// all are used
+func (runtime *browserRuntime) freeTouch() Button {
+	for _, button := range []Button{TouchFirst, TouchSecond, TouchThird} {
+		used := false
+		for _, touch := range runtime.touches {
+			used = used || touch == button
+		}
+		if !used {
+			return button
+		}
+	}
+	return ButtonNone
+}
+
+// -------------------------------------------------------------------- //
+// Gamepads
+// -------------------------------------------------------------------- //
+
+// browserGamepad is the last known state of a gamepad, buttons values are stored
+// to publish changes as the Gamepad API has no events for buttons and axes
+type browserGamepad struct {
+	buttons []float64
+	axes    []float64
+}
+
+// Buttons of the standard mapping, triggers (6 and 7) are published as axes
+var gamepadButtonMap = []GamepadButton{
+	GamepadButtonA, GamepadButtonB, GamepadButtonX, GamepadButtonY,
+	GamepadButtonLeftShoulder, GamepadButtonRightShoulder, GamepadButtonNone, GamepadButtonNone,
+	GamepadButtonBack, GamepadButtonStart, GamepadButtonLeftStick, GamepadButtonRightStick,
+	GamepadButtonDpadUp, GamepadButtonDpadDown, GamepadButtonDpadLeft, GamepadButtonDpadRight,
+	GamepadButtonGuide,
+}
+
+var gamepadTriggerMap = map[int]GamepadAxis{6: GamepadAxisTriggerLeft, 7: GamepadAxisTriggerRight}
+
+var gamepadAxisMap = []GamepadAxis{GamepadAxisLeftX, GamepadAxisLeftY, GamepadAxisRightX, GamepadAxisRightY}
+
+// pollGamepads publishes the changes of gamepads using the standard mapping since last call
+func (runtime *browserRuntime) pollGamepads() {
+	navigator := js.Global().Get("navigator")
+	if navigator.Get("getGamepads").IsUndefined() {
+		return
+	}
+	gamepads := navigator.Call("getGamepads")
+	connected := make(map[int32]bool)
+	for i := 0; i < gamepads.Length(); i++ {
+		gamepad := gamepads.Index(i)
+		if gamepad.IsNull() || gamepad.IsUndefined() || !gamepad.Get("connected").Bool() ||
+			gamepad.Get("mapping").String() != "standard" {
+			continue
+		}
+		index := int32(gamepad.Get("index").Int())
+		buttons, axes := gamepad.Get("buttons"), gamepad.Get("axes")
+		state, found := runtime.gamepads[index]
+		if !found {
+			state = &browserGamepad{buttons: make([]float64, buttons.Length()), axes: make([]float64, axes.Length())}
+			runtime.gamepads[index] = state
+			_logger.Info("Gamepad connected", "gamepad", index, "name", gamepad.Get("id").String())
+		}
+		connected[index] = true
+
+		for b := 0; b < len(state.buttons) && b < len(gamepadButtonMap); b++ {
+			value := buttons.Index(b).Get("value").Float()
+			if axis, found := gamepadTriggerMap[b]; found {
+				if value != state.buttons[b] {
+					runtime.Publish(GamepadEvent{Gamepad: index, Axis: axis, Value: float32(value), Type: TypeMove})
+				}
+			} else {
+				if buttons.Index(b).Get("pressed").Bool() {
+					value = 1
+				} else {
+					value = 0
+				}
+				if value != state.buttons[b] {
+					eventType := TypeUp
+					if value != 0 {
+						eventType = TypeDown
+					}
+					runtime.Publish(GamepadEvent{Gamepad: index, Button: gamepadButtonMap[b], Type: eventType})
+				}
+			}
+			state.buttons[b] = value
+		}
+
+		for a := 0; a < len(state.axes) && a < len(gamepadAxisMap); a++ {
+			value := axes.Index(a).Float()
+			if value != state.axes[a] {
+				runtime.Publish(GamepadEvent{Gamepad: index, Axis: gamepadAxisMap[a], Value: float32(value), Type: TypeMove})
+			}
+			state.axes[a] = value
+		}
+	}
+
+	for index := range runtime.gamepads {
+		if !connected[index] {
+			delete(runtime.gamepads, index)
+			_logger.Info("Gamepad disconnected", "gamepad", index)
+		}
+	}
+}
+
 // -------------------------------------------------------------------- //
 // KeyMap
 // -------------------------------------------------------------------- //
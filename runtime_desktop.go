@@ -9,11 +9,10 @@
 package tge
 
 import (
-	fmt "fmt"
-	ioutil "io/ioutil"
+	bytes "bytes"
+	fs "io/fs"
 	math "math"
 	os "os"
-	path "path"
 	filepath "path/filepath"
 	runtime "runtime"
 	sync "sync"
@@ -25,13 +24,13 @@ import (
 // init ensure that we're running on main thread
 func init() {
 	runtime.LockOSThread()
-	_runtimeInstance = &desktopRuntime{}
 }
 
 // -------------------------------------------------------------------- //
 // Runtime implementation
 // -------------------------------------------------------------------- //
 type desktopRuntime struct {
+	runtimeCore
 	app        App
 	host       *sdl.Window
 	context    *sdl.GLContext
@@ -41,10 +40,6 @@ type desktopRuntime struct {
 	assetsPath string
 }
 
-func (runtime *desktopRuntime) GetAsset(p string) ([]byte, error) {
-	return ioutil.ReadFile(path.Join(runtime.assetsPath, p))
-}
-
 func (runtime *desktopRuntime) GetHost() interface{} {
 	return runtime.host
 }
@@ -54,53 +49,139 @@ func (runtime *desktopRuntime) GetRenderer() interface{} {
 }
 
 func (runtime *desktopRuntime) GetSettings() Settings {
-	return runtime.settings
-}
-
-func (runtime *desktopRuntime) Subscribe(channel string, listener Listener) {
-	subscribe(channel, listener)
-}
-
-func (runtime *desktopRuntime) Unsubscribe(channel string, listener Listener) {
-	unsubscribe(channel, listener)
-}
-
-func (runtime *desktopRuntime) Publish(event Event) {
-	publish(event)
+	settings := runtime.settings.clone()
+	settings.EventMask = runtime.getEventMask()
+	settings.Actions = runtime.actions.getBindings()
+	return settings
 }
 
 func (runtime *desktopRuntime) Stop() {
 	if !runtime.isPaused {
 		runtime.isPaused = true
 		runtime.app.OnPause()
+		runtime.pausePlugins()
 	}
 	runtime.isStopped = true
 	runtime.app.OnStop()
 }
 
+func (runtime *desktopRuntime) ShowKeyboard(keyboardType KeyboardType) {
+	// Physical keyboard, text input is always available
+}
+
+func (runtime *desktopRuntime) HideKeyboard() {
+	// Physical keyboard, text input is always available
+}
+
+// AssetsDirEnv is the environment variable overriding the directory of assets on desktop
+const AssetsDirEnv = "TGE_ASSETS_DIR"
+
+// desktopAssets returns the assets file system, its directory if any and if it is the
+// development one (source tree of the App), the first
+// location found is used among:
+//   - the directory set in AssetsDirEnv environment variable
+//   - the file system given by WithAssets() option
+//   - the directory given by WithAssetsDir() option
+//   - the directory set in Settings.AssetsDir
+//   - the default lookup relative to the executable
+func desktopAssets(opts *options, settings Settings) (fs.FS, string, bool, error) {
+	dir := os.Getenv(AssetsDirEnv)
+	if dir == "" {
+		if opts.assets != nil {
+			return assetFS{opts.assets}, "", false, nil
+		}
+		dir = opts.assetsDir
+	}
+	if dir == "" {
+		dir = settings.AssetsDir
+	}
+
+	if dir != "" {
+		var err error
+		if dir, err = filepath.Abs(dir); err != nil {
+			return nil, "", false, err
+		}
+		if info, err := os.Stat(dir); err != nil {
+			return nil, "", false, err
+		} else if !info.IsDir() {
+			return nil, "", false, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrInvalid}
+		}
+		return assetFS{os.DirFS(dir)}, dir, false, nil
+	}
+
+	p, err := os.Executable()
+	if err != nil {
+		return nil, "", false, err
+	}
+	if p, err = filepath.EvalSymlinks(p); err != nil {
+		return nil, "", false, err
+	}
+	if runtime.GOOS == "darwin" {
+		// Packed mode (DIST for darwin)
+		dir = filepath.Join(filepath.Dir(p), "../Resources")
+	} else {
+		// Unpacked mode (DIST for windows/linux)
+		dir = filepath.Join(filepath.Dir(p), "assets")
+	}
+	dev := false
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// Unpacked mode (DEV for all)
+		dir = filepath.Join(filepath.Dir(p), "../../assets")
+		dev = true
+	}
+	return assetFS{os.DirFS(dir)}, dir, dev, nil
+}
+
 // Run main entry point of runtime
-func Run(app App) error {
+func Run(app App, options ...Option) error {
 	// -------------------------------------------------------------------- //
 	// Create
 	// -------------------------------------------------------------------- //
-	settings := defaultSettings
-	err := app.OnCreate(&settings)
+	opts := newOptions(options)
+	// Settings file is read from assets default location
+	settingsAssets, _, _, err := desktopAssets(opts, Settings{})
+	if err != nil {
+		_logger.Error("Failed to find assets", "error", err)
+		panic(err)
+	}
+	settings, userFS, err := loadSettings(settingsAssets, commandLineSettings())
+	if err != nil {
+		_logger.Error("Failed to load settings", "error", err)
+		panic(err)
+	}
+	// UserFS stays located with the name known before OnCreate()
+	name := settings.Name
+	err = app.OnCreate(&settings)
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to create App", "error", err)
 		panic(err)
 	}
 	defer app.OnDispose()
+	if settings.Name != name {
+		_logger.Warn("Name set by App is not used to locate the user file system", "name", name)
+	}
+	userFS.setQuota(settings.UserFSQuota)
+	_logger.setDefaultLevel(settings.LogLevel)
+	if err = settings.Validate(); err != nil {
+		_logger.Error("Invalid settings", "error", err)
+		panic(err)
+	}
 
 	// -------------------------------------------------------------------- //
 	// Init
 	// -------------------------------------------------------------------- //
 	sdl.SetHint(sdl.HINT_VIDEO_HIGHDPI_DISABLED, "1")
 	if err = sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to initialize SDL", "error", err)
 		panic(err)
 	}
 	defer sdl.Quit()
 
+	if !settings.Fullscreen {
+		bounds := desktopDisplayBounds(settings)
+		settings.clampWindow(int(bounds.X), int(bounds.Y), int(bounds.W), int(bounds.H))
+	}
+
 	windowFlags := sdl.WINDOW_OPENGL | sdl.WINDOW_RESIZABLE
 	if settings.Fullscreen {
 		windowFlags = windowFlags | sdl.WINDOW_FULLSCREEN_DESKTOP
@@ -115,61 +196,77 @@ func Run(app App) error {
 	sdl.GLSetAttribute(sdl.GL_MULTISAMPLESAMPLES, 8)
 
 	// Window creation
-	window, err := sdl.CreateWindow(settings.Name, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+	windowX, windowY := int32(sdl.WINDOWPOS_UNDEFINED), int32(sdl.WINDOWPOS_UNDEFINED)
+	if settings.X != PositionUndefined && settings.Y != PositionUndefined {
+		windowX, windowY = int32(settings.X), int32(settings.Y)
+	}
+	window, err := sdl.CreateWindow(settings.Name, windowX, windowY,
 		int32(settings.Width), int32(settings.Height), uint32(windowFlags))
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to create window", "error", err)
 		panic(err)
 	}
 	defer window.Destroy()
 
 	context, err := window.GLCreateContext()
 	if err != nil {
+		_logger.Error("Failed to create GL context", "error", err)
 		panic(err)
 	}
 
 	// Instanciate Runtime
-	desktopRuntime := _runtimeInstance.(*desktopRuntime)
+	desktopRuntime := &desktopRuntime{}
+	if err = desktopRuntime.initCore(opts); err != nil {
+		_logger.Error("Failed to create Runtime", "error", err)
+		panic(err)
+	}
 	desktopRuntime.app = app
 	desktopRuntime.host = window
 	desktopRuntime.context = &context
 	desktopRuntime.settings = settings
+	desktopRuntime.SetEventMask(settings.EventMask)
+	desktopRuntime.initActions(settings, userFS)
 	desktopRuntime.isPaused = true
 	desktopRuntime.isStopped = true
 
-	// Init plugins
-	initPlugins()
-
 	// Eval assets path
-	if p, err := os.Executable(); err != nil {
+	assets, assetsPath, isDevAssets, err := desktopAssets(opts, settings)
+	if err != nil {
+		_logger.Error("Failed to find assets", "error", err)
 		panic(err)
-	} else {
-		if p, err = filepath.EvalSymlinks(p); err != nil {
-			panic(err)
-		}
-		if runtime.GOOS == "darwin" {
-			// Packed mode (DIST for darwin)
-			desktopRuntime.assetsPath = filepath.Join(filepath.Dir(p), "../Resources")
-		} else {
-			// Unpacked mode (DIST for windows/linux)
-			desktopRuntime.assetsPath = filepath.Join(filepath.Dir(p), "assets")
-		}
-
-		if _, err := os.Stat(desktopRuntime.assetsPath); os.IsNotExist(err) {
-			// Unpacked mode (DEV for all)
-			desktopRuntime.assetsPath = filepath.Join(filepath.Dir(p), "../../assets")
-		}
+	}
+	desktopRuntime.assetsPath = assetsPath
+	desktopRuntime.storage = newStorage(newStorageBackend(settings))
+	desktopRuntime.userFS = userFS
+	// High DPI is disabled, logical and physical pixels are the same
+	desktopRuntime.initLocale(settings, 1)
+	desktopRuntime.initAssets(assets, settings)
+
+	// Hot reload of assets in DEV mode
+	if isDevAssets {
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		go desktopRuntime.watchAssets(assetsPath, stopWatch)
+	}
 
+	// Record or replay input events
+	if err = desktopRuntime.initRecording(opts, settings); err != nil {
+		_logger.Error("Failed to start recording", "error", err)
+		panic(err)
 	}
+	defer desktopRuntime.disposeRecording()
+
+	// Init plugins
+	desktopRuntime.initPlugins(desktopRuntime)
 
 	// Unload plugins
-	defer dispose()
+	defer desktopRuntime.dispose()
 	defer sdl.GLDeleteContext(context)
 
 	// Start App
 	err = app.OnStart(desktopRuntime)
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to start App", "error", err)
 		panic(err)
 	}
 	desktopRuntime.isStopped = false
@@ -183,7 +280,10 @@ func Run(app App) error {
 		for !desktopRuntime.isStopped {
 			if !desktopRuntime.isPaused {
 				now := time.Now()
+				elapsedTpsTime = desktopRuntime.beginTick(elapsedTpsTime)
+				desktopRuntime.preTickPlugins(elapsedTpsTime)
 				app.OnTick(elapsedTpsTime, syncChan)
+				desktopRuntime.postTickPlugins(elapsedTpsTime)
 				elapsedTpsTime = time.Since(now)
 			}
 		}
@@ -193,6 +293,14 @@ func Run(app App) error {
 	// Render Loop
 	// -------------------------------------------------------------------- //
 	var resizeAtStart sync.Once
+	controllers := make(map[sdl.JoystickID]*sdl.GameController)
+	defer func() {
+		for _, controller := range controllers {
+			controller.Close()
+		}
+	}()
+	// Window changes made by the user are saved at exit
+	windowSettings := settings
 	elapsedFpsTime := time.Duration(0)
 	for !desktopRuntime.isStopped {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
@@ -202,25 +310,35 @@ func Run(app App) error {
 			case *sdl.WindowEvent:
 				switch t.Event {
 				case sdl.WINDOWEVENT_FOCUS_GAINED:
+					desktopRuntime.resumePlugins()
 					app.OnResume()
 					desktopRuntime.isPaused = false
 					resizeAtStart.Do(func() {
 						w, h := window.GetSize()
-						if runtime.GOOS != "windows" {
-							publish(ResizeEvent{w, h})
-						} else if !settings.Fullscreen {
-							publish(ResizeEvent{w, h})
+						if runtime.GOOS != "windows" || !settings.Fullscreen {
+							desktopRuntime.resizePlugins(w, h)
+							desktopRuntime.Publish(ResizeEvent{w, h})
 						}
 					})
 				case sdl.WINDOWEVENT_FOCUS_LOST:
+					desktopRuntime.releaseInputs()
 					desktopRuntime.isPaused = true
 					app.OnPause()
+					desktopRuntime.pausePlugins()
 				case sdl.WINDOWEVENT_RESIZED:
 					w, h := window.GetSize()
-					publish(ResizeEvent{w, h})
+					desktopRuntime.resizePlugins(w, h)
+					desktopRuntime.Publish(ResizeEvent{w, h})
+					if window.GetFlags()&sdl.WINDOW_FULLSCREEN == 0 {
+						windowSettings.Width, windowSettings.Height = int(w), int(h)
+					}
+				case sdl.WINDOWEVENT_MOVED:
+					if window.GetFlags()&sdl.WINDOW_FULLSCREEN == 0 {
+						windowSettings.X, windowSettings.Y = int(t.Data1), int(t.Data2)
+					}
 				}
 			case *sdl.MouseButtonEvent:
-				if (settings.EventMask & MouseButtonEventEnabled) != 0 {
+				if desktopRuntime.isEventEnabled(MouseButtonEventEnabled) {
 					button := ButtonNone
 					switch t.Button {
 					case 1:
@@ -230,7 +348,7 @@ func Run(app App) error {
 					case 3:
 						button = ButtonRight
 					}
-					publish(MouseEvent{
+					desktopRuntime.Publish(MouseEvent{
 						X:      t.X,
 						Y:      t.Y,
 						Type:   Type(t.Type),
@@ -238,8 +356,8 @@ func Run(app App) error {
 					})
 				}
 			case *sdl.MouseMotionEvent:
-				if (settings.EventMask & MouseMotionEventEnabled) != 0 {
-					publish(MouseEvent{
+				if desktopRuntime.isEventEnabled(MouseMotionEventEnabled) {
+					desktopRuntime.Publish(MouseEvent{
 						X:      t.X,
 						Y:      t.Y,
 						Type:   TypeMove,
@@ -247,7 +365,7 @@ func Run(app App) error {
 					})
 				}
 			case *sdl.MouseWheelEvent:
-				if (settings.EventMask & ScrollEventEnabled) != 0 {
+				if desktopRuntime.isEventEnabled(ScrollEventEnabled) {
 					x := float64(t.X)
 					y := float64(t.Y)
 					if x != 0 {
@@ -256,35 +374,167 @@ func Run(app App) error {
 					if y != 0 {
 						y = y / math.Abs(y)
 					}
-					publish(ScrollEvent{
+					desktopRuntime.Publish(ScrollEvent{
 						X: int32(x),
 						Y: int32(y),
 					})
 				}
 			case *sdl.KeyboardEvent:
-				if (settings.EventMask & KeyEventEnabled) != 0 {
+				if desktopRuntime.isEventEnabled(KeyEventEnabled) {
 					keyCode := sdl.GetKeyName(t.Keysym.Sym)
-					publish(KeyEvent{
-						Type:  Type(t.Type),
+					eventType := TypeUp
+					if t.State == sdl.PRESSED {
+						eventType = TypeDown
+					}
+					desktopRuntime.Publish(KeyEvent{
+						Type:  eventType,
 						Key:   keyMap[keyCode],
 						Value: keyCode,
 					})
 				}
+			case *sdl.TextInputEvent:
+				if desktopRuntime.isEventEnabled(TextInputEventEnabled) {
+					text := t.Text[:]
+					if i := bytes.IndexByte(text, 0); i >= 0 {
+						text = text[:i]
+					}
+					desktopRuntime.Publish(TextInputEvent{Text: string(text)})
+				}
+			case *sdl.ControllerDeviceEvent:
+				switch t.Type {
+				case sdl.CONTROLLERDEVICEADDED:
+					if controller := sdl.GameControllerOpen(int(t.Which)); controller != nil {
+						controllers[controller.Joystick().InstanceID()] = controller
+						_logger.Info("Gamepad connected", "gamepad", controller.Joystick().InstanceID(), "name", controller.Name())
+					}
+				case sdl.CONTROLLERDEVICEREMOVED:
+					if controller, found := controllers[t.Which]; found {
+						controller.Close()
+						delete(controllers, t.Which)
+						_logger.Info("Gamepad disconnected", "gamepad", t.Which)
+					}
+				}
+			case *sdl.ControllerButtonEvent:
+				if desktopRuntime.isEventEnabled(GamepadEventEnabled) {
+					eventType := TypeUp
+					if t.State == sdl.PRESSED {
+						eventType = TypeDown
+					}
+					desktopRuntime.Publish(GamepadEvent{
+						Gamepad: int32(t.Which),
+						Button:  gamepadButtonMap[t.Button],
+						Type:    eventType,
+					})
+				}
+			case *sdl.ControllerAxisEvent:
+				if desktopRuntime.isEventEnabled(GamepadEventEnabled) {
+					value := float32(t.Value) / 32767
+					if value < -1 {
+						value = -1
+					}
+					desktopRuntime.Publish(GamepadEvent{
+						Gamepad: int32(t.Which),
+						Axis:    gamepadAxisMap[t.Axis],
+						Value:   value,
+						Type:    TypeMove,
+					})
+				}
 			}
 		}
 		if !desktopRuntime.isPaused {
 			now := time.Now()
+			desktopRuntime.preRenderPlugins(elapsedFpsTime)
 			app.OnRender(elapsedFpsTime, syncChan)
+			desktopRuntime.postRenderPlugins(elapsedFpsTime)
 			window.GLSwap()
 			elapsedFpsTime = time.Since(now)
 		}
 	}
 
+	windowSettings.Fullscreen = window.GetFlags()&sdl.WINDOW_FULLSCREEN != 0
+	desktopRuntime.saveWindowSettings(settings, windowSettings)
+
 	runtime.UnlockOSThread()
 
 	return nil
 }
 
+// desktopDisplayBounds returns the usable bounds of the display containing the
+// window position, the first display is used by default
+func desktopDisplayBounds(settings Settings) sdl.Rect {
+	count, err := sdl.GetNumVideoDisplays()
+	if err != nil {
+		count = 1
+	}
+	var first sdl.Rect
+	for i := 0; i < count; i++ {
+		bounds, err := sdl.GetDisplayUsableBounds(i)
+		if err != nil {
+			continue
+		}
+		if i == 0 {
+			first = bounds
+		}
+		if settings.X != PositionUndefined && settings.Y != PositionUndefined &&
+			(&sdl.Point{X: int32(settings.X), Y: int32(settings.Y)}).InRect(&bounds) {
+			return bounds
+		}
+	}
+	return first
+}
+
+// saveWindowSettings saves in the user settings of the App the window values changed
+// since launch, they are restored before OnCreate() at next launch
+func (runtime *desktopRuntime) saveWindowSettings(launched Settings, current Settings) {
+	values := make(map[string]interface{})
+	if current.Fullscreen != launched.Fullscreen {
+		values["fullscreen"] = current.Fullscreen
+	}
+	if current.Width != launched.Width || current.Height != launched.Height {
+		values["width"], values["height"] = current.Width, current.Height
+	}
+	if current.X != launched.X || current.Y != launched.Y {
+		values["x"], values["y"] = current.X, current.Y
+	}
+	if runtime.userFS == nil || len(values) == 0 {
+		return
+	}
+	if err := saveUserSettings(runtime.userFS, values); err != nil {
+		_logger.Error("Failed to save window settings", "error", err)
+	}
+}
+
+// -------------------------------------------------------------------- //
+// GamepadMap
+// -------------------------------------------------------------------- //
+
+var gamepadButtonMap = map[uint8]GamepadButton{
+	sdl.CONTROLLER_BUTTON_A:             GamepadButtonA,
+	sdl.CONTROLLER_BUTTON_B:             GamepadButtonB,
+	sdl.CONTROLLER_BUTTON_X:             GamepadButtonX,
+	sdl.CONTROLLER_BUTTON_Y:             GamepadButtonY,
+	sdl.CONTROLLER_BUTTON_BACK:          GamepadButtonBack,
+	sdl.CONTROLLER_BUTTON_GUIDE:         GamepadButtonGuide,
+	sdl.CONTROLLER_BUTTON_START:         GamepadButtonStart,
+	sdl.CONTROLLER_BUTTON_LEFTSTICK:     GamepadButtonLeftStick,
+	sdl.CONTROLLER_BUTTON_RIGHTSTICK:    GamepadButtonRightStick,
+	sdl.CONTROLLER_BUTTON_LEFTSHOULDER:  GamepadButtonLeftShoulder,
+	sdl.CONTROLLER_BUTTON_RIGHTSHOULDER: GamepadButtonRightShoulder,
+	sdl.CONTROLLER_BUTTON_DPAD_UP:       GamepadButtonDpadUp,
+	sdl.CONTROLLER_BUTTON_DPAD_DOWN:     GamepadButtonDpadDown,
+	sdl.CONTROLLER_BUTTON_DPAD_LEFT:     GamepadButtonDpadLeft,
+	sdl.CONTROLLER_BUTTON_DPAD_RIGHT:    GamepadButtonDpadRight,
+}
+
+var gamepadAxisMap = map[uint8]GamepadAxis{
+	sdl.CONTROLLER_AXIS_LEFTX:        GamepadAxisLeftX,
+	sdl.CONTROLLER_AXIS_LEFTY:        GamepadAxisLeftY,
+	sdl.CONTROLLER_AXIS_RIGHTX:       GamepadAxisRightX,
+	sdl.CONTROLLER_AXIS_RIGHTY:       GamepadAxisRightY,
+	sdl.CONTROLLER_AXIS_TRIGGERLEFT:  GamepadAxisTriggerLeft,
+	sdl.CONTROLLER_AXIS_TRIGGERRIGHT: GamepadAxisTriggerRight,
+}
+
 // -------------------------------------------------------------------- //
 // KeyMap
 // -------------------------------------------------------------------- //
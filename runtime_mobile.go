@@ -6,12 +6,12 @@
 package tge
 
 import (
-	fmt "fmt"
-	ioutil "io/ioutil"
+	fs "io/fs"
 	time "time"
+	unicode "unicode"
 
 	mobile "github.com/thommil/tge-mobile/app"
-	asset "github.com/thommil/tge-mobile/asset"
+	key "github.com/thommil/tge-mobile/event/key"
 	lifecycle "github.com/thommil/tge-mobile/event/lifecycle"
 	paint "github.com/thommil/tge-mobile/event/paint"
 	size "github.com/thommil/tge-mobile/event/size"
@@ -19,14 +19,11 @@ import (
 	gl "github.com/thommil/tge-mobile/gl"
 )
 
-func init() {
-	_runtimeInstance = &mobileRuntime{}
-}
-
 // -------------------------------------------------------------------- //
 // Runtime implementation
 // -------------------------------------------------------------------- //
 type mobileRuntime struct {
+	runtimeCore
 	app       App
 	host      mobile.App
 	context   gl.Context
@@ -35,14 +32,6 @@ type mobileRuntime struct {
 	isStopped bool
 }
 
-func (runtime *mobileRuntime) GetAsset(p string) ([]byte, error) {
-	if file, err := asset.Open(p); err != nil {
-		return nil, err
-	} else {
-		return ioutil.ReadAll(file)
-	}
-}
-
 func (runtime *mobileRuntime) GetHost() interface{} {
 	return runtime.host
 }
@@ -52,45 +41,103 @@ func (runtime *mobileRuntime) GetRenderer() interface{} {
 }
 
 func (runtime *mobileRuntime) GetSettings() Settings {
-	return runtime.settings
+	settings := runtime.settings.clone()
+	settings.EventMask = runtime.getEventMask()
+	settings.Actions = runtime.actions.getBindings()
+	return settings
 }
 
-func (runtime *mobileRuntime) Subscribe(channel string, listener Listener) {
-	subscribe(channel, listener)
+func (runtime *mobileRuntime) Stop() {
+	// Not implemented
 }
 
-func (runtime *mobileRuntime) Unsubscribe(channel string, listener Listener) {
-	unsubscribe(channel, listener)
+func (runtime *mobileRuntime) ShowKeyboard(keyboardType KeyboardType) {
+	if err := showKeyboard(keyboardType, runtime.publishKeyboardEvent); err != nil {
+		_logger.Error("Failed to show keyboard", "error", err)
+	}
 }
 
-func (runtime *mobileRuntime) Publish(event Event) {
-	publish(event)
+// publishKeyboardEvent publishes the events of soft keyboards which are not received
+// as key.Event (iOS)
+func (runtime *mobileRuntime) publishKeyboardEvent(event Event) {
+	switch event.(type) {
+	case KeyEvent:
+		if !runtime.isEventEnabled(KeyEventEnabled) {
+			return
+		}
+	case TextInputEvent:
+		if !runtime.isEventEnabled(TextInputEventEnabled) {
+			return
+		}
+	}
+	runtime.Publish(event)
 }
 
-func (runtime *mobileRuntime) Stop() {
-	// Not implemented
+func (runtime *mobileRuntime) HideKeyboard() {
+	if err := hideKeyboard(); err != nil {
+		_logger.Error("Failed to hide keyboard", "error", err)
+	}
 }
 
 // Run main entry point of runtime
-func Run(app App) error {
+func Run(app App, options ...Option) error {
 	// -------------------------------------------------------------------- //
 	// Create
 	// -------------------------------------------------------------------- //
-	settings := defaultSettings
-	err := app.OnCreate(&settings)
+	opts := newOptions(options)
+	var settingsAssets fs.FS = mobileAssetFS{}
+	if opts.assets != nil {
+		settingsAssets = opts.assets
+	}
+	settings, userFS, err := loadSettings(settingsAssets, nil)
 	if err != nil {
-		fmt.Println(err)
+		_logger.Error("Failed to load settings", "error", err)
+		panic(err)
+	}
+	// UserFS stays located with the name known before OnCreate()
+	name := settings.Name
+	err = app.OnCreate(&settings)
+	if err != nil {
+		_logger.Error("Failed to create App", "error", err)
 		panic(err)
 	}
 	defer app.OnDispose()
+	if settings.Name != name {
+		_logger.Warn("Name set by App is not used to locate the user file system", "name", name)
+	}
+	userFS.setQuota(settings.UserFSQuota)
+	_logger.setDefaultLevel(settings.LogLevel)
+	if err = settings.Validate(); err != nil {
+		_logger.Error("Invalid settings", "error", err)
+		panic(err)
+	}
 
 	// Instanciate Runtime
-	mobileRuntime := _runtimeInstance.(*mobileRuntime)
+	mobileRuntime := &mobileRuntime{}
+	if err = mobileRuntime.initCore(opts); err != nil {
+		_logger.Error("Failed to create Runtime", "error", err)
+		panic(err)
+	}
 	mobileRuntime.app = app
+	mobileRuntime.storage = newStorage(newStorageBackend(settings))
+	mobileRuntime.userFS = userFS
+	mobileRuntime.initLocale(settings, 1)
+	if opts.assets != nil {
+		mobileRuntime.initAssets(assetFS{opts.assets}, settings)
+	} else {
+		mobileRuntime.initAssets(assetFS{mobileAssetFS{}}, settings)
+	}
 	mobileRuntime.settings = settings
+	mobileRuntime.SetEventMask(settings.EventMask)
+	mobileRuntime.initActions(settings, userFS)
+	if err = mobileRuntime.initRecording(opts, settings); err != nil {
+		_logger.Error("Failed to start recording", "error", err)
+		panic(err)
+	}
+	defer mobileRuntime.disposeRecording()
 	mobileRuntime.isPaused = true
 	mobileRuntime.isStopped = true
-	defer dispose()
+	defer mobileRuntime.dispose()
 
 	// -------------------------------------------------------------------- //
 	// Ticker Loop
@@ -101,7 +148,10 @@ func Run(app App) error {
 		for !mobileRuntime.isStopped {
 			if !mobileRuntime.isPaused {
 				now := time.Now()
+				elapsedTpsTime = mobileRuntime.beginTick(elapsedTpsTime)
+				mobileRuntime.preTickPlugins(elapsedTpsTime)
 				app.OnTick(elapsedTpsTime, syncChan)
+				mobileRuntime.postTickPlugins(elapsedTpsTime)
 				elapsedTpsTime = time.Since(now)
 			}
 		}
@@ -121,44 +171,54 @@ func Run(app App) error {
 					mobileRuntime.context, _ = e.DrawContext.(gl.Context)
 					mobileRuntime.host = a
 
+					// Packs are closed when the Runtime is disposed
+					mobileRuntime.mountAssetPacks(settings)
+
 					// Init plugins
-					initPlugins()
+					mobileRuntime.initPlugins(mobileRuntime)
 
 					err := app.OnStart(mobileRuntime)
 					if err != nil {
-						fmt.Println(err)
+						_logger.Error("Failed to start App", "error", err)
 						panic(err)
 					}
 					mobileRuntime.isStopped = false
 					go startTicker()
+					mobileRuntime.resumePlugins()
 					app.OnResume()
 					mobileRuntime.isPaused = false
 
 					// Mouse motion hack to queue move events
 					moveEvtChan = make(chan MouseEvent, 100)
-					go func() {
-						for !mobileRuntime.isStopped {
-							publish(<-moveEvtChan)
+					go func(moveEvtChan chan MouseEvent) {
+						// Queued events are published until the channel is closed on StageAlive
+						for e := range moveEvtChan {
+							mobileRuntime.Publish(e)
 						}
-					}()
+					}(moveEvtChan)
 
 				case lifecycle.StageAlive:
 					mobileRuntime.isPaused = true
 					app.OnPause()
+					mobileRuntime.pausePlugins()
 					mobileRuntime.isStopped = true
 					close(moveEvtChan)
+					mobileRuntime.releaseInputs()
 					app.OnStop()
 					mobileRuntime.context = nil
 
-					// Release plugins
-					dispose()
+					// Release plugins, the recording is kept until the end of Run
+					mobileRuntime.dispose()
+					mobileRuntime.flushRecording()
 				}
 
 			case paint.Event:
 				if !mobileRuntime.isPaused {
 					if mobileRuntime.context != nil && !e.External {
 						now := time.Now()
+						mobileRuntime.preRenderPlugins(elapsedFpsTime)
 						app.OnRender(elapsedFpsTime, syncChan)
+						mobileRuntime.postRenderPlugins(elapsedFpsTime)
 						a.Publish()
 						elapsedFpsTime = time.Since(now)
 					}
@@ -166,9 +226,34 @@ func Run(app App) error {
 				}
 
 			case size.Event:
-				publish(ResizeEvent{int32(e.WidthPx), int32(e.HeightPx)})
+				// Density is given relatively to the 160 dpi baseline of logical pixels
+				mobileRuntime.setPixelDensity(float32(e.PixelsPerPt) * 72 / 160)
+				mobileRuntime.resizePlugins(int32(e.WidthPx), int32(e.HeightPx))
+				mobileRuntime.Publish(ResizeEvent{int32(e.WidthPx), int32(e.HeightPx)})
+
+			case key.Event:
+				// Virtual keyboard, text is published as TextInputEvent and editing keys as KeyEvent
+				if keyCode, found := mobileKeyMap[e.Code]; found && e.Direction != key.DirNone &&
+					mobileRuntime.isEventEnabled(KeyEventEnabled) {
+					eventType := TypeDown
+					if e.Direction == key.DirRelease {
+						eventType = TypeUp
+					}
+					mobileRuntime.Publish(KeyEvent{
+						Key:   keyCode,
+						Value: keyCode.String(),
+						Type:  eventType,
+					})
+				} else if e.Direction == key.DirPress && unicode.IsPrint(e.Rune) &&
+					mobileRuntime.isEventEnabled(TextInputEventEnabled) {
+					mobileRuntime.Publish(TextInputEvent{Text: string(e.Rune)})
+				}
 
 			case touch.Event:
+				if mobileRuntime.isStopped {
+					// The queue of move events is closed until next StageFocused
+					break
+				}
 				button := ButtonNone
 				switch e.Sequence {
 				case 0:
@@ -181,7 +266,7 @@ func Run(app App) error {
 				switch e.Type {
 				case touch.TypeBegin:
 					// mouse down
-					if (settings.EventMask & MouseButtonEventEnabled) != 0 {
+					if mobileRuntime.isTouchEventEnabled(TypeDown) {
 						moveEvtChan <- MouseEvent{
 							X:      int32(e.X),
 							Y:      int32(e.Y),
@@ -191,7 +276,7 @@ func Run(app App) error {
 					}
 				case touch.TypeMove:
 					// mouse move
-					if (settings.EventMask & MouseMotionEventEnabled) != 0 {
+					if mobileRuntime.isTouchEventEnabled(TypeMove) {
 						moveEvtChan <- MouseEvent{
 							X:      int32(e.X),
 							Y:      int32(e.Y),
@@ -200,8 +285,8 @@ func Run(app App) error {
 						}
 					}
 				case touch.TypeEnd:
-					// Touch down
-					if (settings.EventMask & MouseButtonEventEnabled) != 0 {
+					// Touch up
+					if mobileRuntime.isTouchEventEnabled(TypeUp) {
 						moveEvtChan <- MouseEvent{
 							X:      int32(e.X),
 							Y:      int32(e.Y),
@@ -217,3 +302,18 @@ func Run(app App) error {
 
 	return nil
 }
+
+// Editing keys of virtual keyboards
+var mobileKeyMap = map[key.Code]KeyCode{
+	key.CodeDeleteBackspace: KeyCodeDeleteBackspace,
+	key.CodeDeleteForward:   KeyCodeDeleteForward,
+	key.CodeReturnEnter:     KeyCodeReturnEnter,
+	key.CodeTab:             KeyCodeTab,
+	key.CodeEscape:          KeyCodeEscape,
+	key.CodeHome:            KeyCodeHome,
+	key.CodeEnd:             KeyCodeEnd,
+	key.CodeLeftArrow:       KeyCodeLeftArrow,
+	key.CodeRightArrow:      KeyCodeRightArrow,
+	key.CodeUpArrow:         KeyCodeUpArrow,
+	key.CodeDownArrow:       KeyCodeDownArrow,
+}
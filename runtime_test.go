@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	reflect "reflect"
+	strings "strings"
+	testing "testing"
+)
+
+// testPlugin is a DependentPlugin recording its initialization and disposal
+type testPlugin struct {
+	name         string
+	dependencies []string
+	events       *[]string
+}
+
+func (p *testPlugin) Init(runtime Runtime) error {
+	*p.events = append(*p.events, "init "+p.name)
+	return nil
+}
+
+func (p *testPlugin) GetName() string {
+	return p.name
+}
+
+func (p *testPlugin) Dispose() {
+	*p.events = append(*p.events, "dispose "+p.name)
+}
+
+func (p *testPlugin) Dependencies() []string {
+	return p.dependencies
+}
+
+func TestSortPlugins(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins map[string][]string
+		want    []string
+		err     string
+	}{
+		{"none", map[string][]string{}, []string{}, ""},
+		{"independent", map[string][]string{"b": nil, "a": nil, "c": nil}, []string{"a", "b", "c"}, ""},
+		{"chain", map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}, []string{"c", "b", "a"}, ""},
+		{"diamond", map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}, "d": nil}, []string{"d", "b", "c", "a"}, ""},
+		{"missing", map[string][]string{"a": {"gl"}}, nil, "plugin a depends on gl which is not registered"},
+		{"self", map[string][]string{"a": {"a"}}, nil, "plugins dependency cycle: a -> a"},
+		{"cycle", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}, nil, "plugins dependency cycle: a -> b -> c -> a"},
+		{"cycle after branch", map[string][]string{"a": {"b", "c"}, "b": nil, "c": {"d"}, "d": {"c"}}, nil, "plugins dependency cycle: c -> d -> c"},
+	}
+	for _, test := range tests {
+		events := []string{}
+		core := &runtimeCore{plugins: make(map[string]Plugin)}
+		for name, dependencies := range test.plugins {
+			core.plugins[name] = &testPlugin{name: name, dependencies: dependencies, events: &events}
+		}
+		sorted, err := core.sortPlugins()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: sortPlugins() = %v, want error %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: sortPlugins() failed: %s", test.name, err)
+			continue
+		}
+		names := []string{}
+		for _, plugin := range sorted {
+			names = append(names, plugin.GetName())
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("%s: sortPlugins() = %v, want %v", test.name, names, test.want)
+		}
+	}
+}
+
+func TestPluginsLifecycle(t *testing.T) {
+	events := []string{}
+	core := &runtimeCore{}
+	if err := core.initCore(&options{plugins: []Plugin{
+		&testPlugin{name: "g3n", dependencies: []string{"gl"}, events: &events},
+		&testPlugin{name: "gl", events: &events},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	core.initPlugins(nil)
+	core.dispose()
+	want := []string{"init gl", "init g3n", "dispose g3n", "dispose gl"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("plugins events = %v, want %v", events, want)
+	}
+}
@@ -2,6 +2,23 @@
 
 package tge
 
+import (
+	encoding "encoding"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	fs "io/fs"
+	math "math"
+	os "os"
+	path "path"
+	reflect "reflect"
+	sort "sort"
+	strconv "strconv"
+	strings "strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
 // EventMask defines mask event for enable/disable events receivers at runtime level
 type EventMask int
 
@@ -16,8 +33,18 @@ const (
 	ScrollEventEnabled = 0x04
 	// KeyEventEnabled enabled key event receiver on App
 	KeyEventEnabled = 0x08
+	// TouchEventEnabled enabled touch events receiver on App (mobile and browser), touches
+	// are also received with MouseButtonEventEnabled and MouseMotionEventEnabled
+	TouchEventEnabled = 0x10
+	// GamepadEventEnabled enabled gamepad events receiver on App (desktop and browser)
+	GamepadEventEnabled = 0x20
+	// TextInputEventEnabled enabled text input events receiver on App
+	TextInputEventEnabled = 0x40
+	// GestureEventEnabled enabled gestures recognition from mouse and touch events
+	GestureEventEnabled = 0x80
 	// AllEventsEnabled enables all input events on App
-	AllEventsEnabled = 0xFFFF
+	AllEventsEnabled = MouseButtonEventEnabled | MouseMotionEventEnabled | ScrollEventEnabled | KeyEventEnabled |
+		TouchEventEnabled | GamepadEventEnabled | TextInputEventEnabled | GestureEventEnabled
 )
 
 // Settings definition of TGE application
@@ -30,15 +57,497 @@ type Settings struct {
 	Width int `json:"width" yaml:"width"`
 	// Height of the window if run windowed only
 	Height int `json:"height" yaml:"height"`
+	// X position of the window if run windowed only, PositionUndefined lets the platform choose
+	X int `json:"x" yaml:"x"`
+	// Y position of the window if run windowed only, PositionUndefined lets the platform choose
+	Y int `json:"y" yaml:"y"`
 	// EventMask allows to enabled/disable events receiver on Runtime
 	EventMask EventMask `json:"event_mask" yaml:"event_mask"`
+	// AssetsDir sets the directory of assets on desktop, default lookup is used if empty
+	AssetsDir string `json:"assets_dir" yaml:"assets_dir"`
+	// AssetPacks lists the asset packs to mount at startup, before plugins initialization
+	AssetPacks []string `json:"asset_packs" yaml:"asset_packs"`
+	// AssetCacheSize is the budget in bytes of the assets cache, 0 disables caching
+	AssetCacheSize int64 `json:"asset_cache_size" yaml:"asset_cache_size"`
+	// Locale forces the locale of the App, the platform one is used if empty
+	Locale string `json:"locale" yaml:"locale"`
+	// FallbackLocale is used when no asset variant matches the locale
+	FallbackLocale string `json:"fallback_locale" yaml:"fallback_locale"`
+	// UserFSQuota is the maximum size in bytes of the files written in UserFS, 0 means unlimited
+	UserFSQuota int64 `json:"user_fs_quota" yaml:"user_fs_quota"`
+	// LogLevel defines the minimum level of messages written by the default Runtime Logger
+	LogLevel LogLevel `json:"log_level" yaml:"log_level"`
+	// Actions binds App actions to inputs (see Actions)
+	Actions map[string][]string `json:"actions" yaml:"actions"`
+	// Record is the path in UserFS of a file recording the input events, disabled if empty
+	Record string `json:"record" yaml:"record"`
+	// Replay is the path in UserFS of a recording to replay, disabled if empty
+	Replay string `json:"replay" yaml:"replay"`
+	// Custom holds the App specific settings, they are loaded from the same sources
+	// and can be decoded in App types with DecodeCustom()
+	Custom map[string]interface{} `json:"custom" yaml:"custom"`
 }
 
+// PositionUndefined lets the platform choose the position of the window
+const PositionUndefined = math.MinInt32
+
 // Default settings
 var defaultSettings = Settings{
-	Name:       "TGE Application",
-	Fullscreen: false,
-	Width:      640,
-	Height:     480,
-	EventMask:  AllEventsEnabled,
+	Name:           "TGE Application",
+	Fullscreen:     false,
+	Width:          640,
+	Height:         480,
+	X:              PositionUndefined,
+	Y:              PositionUndefined,
+	EventMask:      AllEventsEnabled,
+	AssetCacheSize: 64 * 1024 * 1024,
+	FallbackLocale: "en",
+	LogLevel:       LogLevelInfo,
+}
+
+// -------------------------------------------------------------------- //
+// Validation
+// -------------------------------------------------------------------- //
+
+// Validate checks that settings can be used by the Runtime, the returned error
+// describes all the invalid values
+func (settings Settings) Validate() error {
+	problems := []string{}
+	if settings.Width <= 0 {
+		problems = append(problems, fmt.Sprintf("width must be positive, got %d", settings.Width))
+	}
+	if settings.Height <= 0 {
+		problems = append(problems, fmt.Sprintf("height must be positive, got %d", settings.Height))
+	}
+	if settings.EventMask < 0 || settings.EventMask&^AllEventsEnabled != 0 {
+		problems = append(problems, fmt.Sprintf("event_mask has unknown bits, got %#x", int(settings.EventMask)))
+	}
+	if settings.AssetCacheSize < 0 {
+		problems = append(problems, fmt.Sprintf("asset_cache_size must not be negative, got %d", settings.AssetCacheSize))
+	}
+	if settings.UserFSQuota < 0 {
+		problems = append(problems, fmt.Sprintf("user_fs_quota must not be negative, got %d", settings.UserFSQuota))
+	}
+	if settings.LogLevel < LogLevelDebug || settings.LogLevel > LogLevelNone {
+		problems = append(problems, fmt.Sprintf("log_level is unknown, got %d", int(settings.LogLevel)))
+	}
+	actions := make([]string, 0, len(settings.Actions))
+	for action := range settings.Actions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		for _, text := range settings.Actions[action] {
+			if _, err := parseBinding(text); err != nil {
+				problems = append(problems, fmt.Sprintf("actions.%s: %v", action, err))
+			}
+		}
+	}
+	if settings.Record != "" && settings.Record == settings.Replay {
+		problems = append(problems, fmt.Sprintf("record and replay must be different files, got %s", settings.Record))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid settings: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// clampWindow reduces the size of the window to fit the display bounds and lets
+// the platform choose the position if the window would be outside the display
+func (settings *Settings) clampWindow(displayX, displayY, displayWidth, displayHeight int) {
+	width, height, x, y := settings.Width, settings.Height, settings.X, settings.Y
+	if settings.Width > displayWidth {
+		settings.Width = displayWidth
+	}
+	if settings.Height > displayHeight {
+		settings.Height = displayHeight
+	}
+	if settings.X != PositionUndefined && (settings.X < displayX || settings.X+settings.Width > displayX+displayWidth) {
+		settings.X = PositionUndefined
+	}
+	if settings.Y != PositionUndefined && (settings.Y < displayY || settings.Y+settings.Height > displayY+displayHeight) {
+		settings.Y = PositionUndefined
+	}
+	if width != settings.Width || height != settings.Height || x != settings.X || y != settings.Y {
+		_logger.Warn("Window clamped to display", "width", settings.Width, "height", settings.Height,
+			"display", fmt.Sprintf("%dx%d", displayWidth, displayHeight))
+	}
+}
+
+// -------------------------------------------------------------------- //
+// Loading
+// -------------------------------------------------------------------- //
+
+// Prefix of environment variables overriding Settings (TGE_WIDTH=800)
+const settingsEnvPrefix = "TGE_"
+
+// Separator of nested Custom keys in environment variables (TGE_CUSTOM_SERVER__URL)
+const settingsEnvSeparator = "__"
+
+// Prefix of command line flags and URL query parameters overriding Settings (-tge.width=800)
+const settingsArgPrefix = "tge."
+
+// Prefix of keys overriding Custom settings (custom.difficulty=hard)
+const settingsCustomPrefix = "custom."
+
+// ErrSettingNotFound is returned by DecodeCustom() if the key is not defined
+var ErrSettingNotFound = errors.New("setting not found")
+
+// Names of settings files looked up in assets and in user data, first found is used
+var settingsFileNames = []string{"settings.json", "settings.yaml", "settings.yml"}
+
+// loadSettings merges in order the default settings, the settings file of assets, the
+// settings file of user data, environment variables and overrides given by the target
+// (command line or URL query). The returned UserFS is located with the name given by
+// all sources but the user file, as it is needed to read it.
+func loadSettings(assets fs.FS, overrides map[string]string) (Settings, *UserFS, error) {
+	settings := defaultSettings.clone()
+	if assets != nil {
+		if err := mergeSettingsFile(&settings, assets); err != nil {
+			return settings, nil, err
+		}
+	}
+
+	located := settings.clone()
+	if err := applySettingsOverrides(&located, overrides); err != nil {
+		return settings, nil, err
+	}
+	userFSBackend, err := newUserFSBackend(located)
+	userFS := newUserFS(userFSBackend, err, located.UserFSQuota)
+	if err == nil {
+		// The name locating user data is not changed by the user file
+		name := settings.Name
+		if err := mergeSettingsFile(&settings, userFS); err != nil {
+			return settings, userFS, err
+		}
+		settings.Name = name
+	}
+
+	if err := applySettingsOverrides(&settings, overrides); err != nil {
+		return settings, userFS, err
+	}
+	userFS.setQuota(settings.UserFSQuota)
+	return settings, userFS, nil
+}
+
+// applySettingsOverrides sets the values of environment variables and overrides
+func applySettingsOverrides(settings *Settings, overrides map[string]string) error {
+	for _, key := range settingsKeys() {
+		if value, found := os.LookupEnv(settingsEnvPrefix + strings.ToUpper(key)); found {
+			if err := settings.set(key, value); err != nil {
+				return fmt.Errorf("invalid environment variable %s%s: %v", settingsEnvPrefix, strings.ToUpper(key), err)
+			}
+		}
+	}
+	customEnvPrefix := settingsEnvPrefix + strings.ToUpper(strings.Replace(settingsCustomPrefix, ".", "_", -1))
+	for _, env := range os.Environ() {
+		if i := strings.Index(env, "="); i > 0 && strings.HasPrefix(env[:i], customEnvPrefix) {
+			// Nested keys are separated by a double underscore (TGE_CUSTOM_SERVER__URL)
+			name := strings.Replace(strings.TrimPrefix(env[:i], customEnvPrefix), settingsEnvSeparator, ".", -1)
+			if err := settings.set(settingsCustomPrefix+strings.ToLower(name), env[i+1:]); err != nil {
+				return fmt.Errorf("invalid environment variable %s: %v", env[:i], err)
+			}
+		}
+	}
+
+	for key, value := range overrides {
+		if err := settings.set(key, value); err != nil {
+			return fmt.Errorf("invalid setting %s%s: %v", settingsArgPrefix, key, err)
+		}
+	}
+	return nil
+}
+
+// mergeSettingsFile overrides settings with the values of the first settings file found in fsys
+func mergeSettingsFile(settings *Settings, fsys fs.FS) error {
+	for _, name := range settingsFileNames {
+		content, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		// Custom values and actions are merged with the ones of previous sources
+		custom, actions := settings.Custom, settings.Actions
+		settings.Custom, settings.Actions = nil, nil
+		if path.Ext(name) == ".json" {
+			err = json.Unmarshal(content, settings)
+		} else {
+			err = yaml.Unmarshal(content, settings)
+		}
+		if err != nil {
+			settings.Custom, settings.Actions = custom, actions
+			return fmt.Errorf("invalid settings file %s: %v", name, err)
+		}
+		settings.Custom = mergeCustom(custom, copyCustom(settings.Custom).(map[string]interface{}))
+		for action, bindings := range settings.Actions {
+			if actions == nil {
+				actions = make(map[string][]string)
+			}
+			actions[action] = bindings
+		}
+		settings.Actions = actions
+		_logger.Debug("Settings file loaded", "file", name)
+		return nil
+	}
+	return nil
+}
+
+// saveUserSettings writes values in the settings file of user data, other values of
+// the file are kept (YAML comments are lost) and settings.json is created if no file exists
+func saveUserSettings(userFS *UserFS, values map[string]interface{}) error {
+	name, content := settingsFileNames[0], []byte(nil)
+	for _, fileName := range settingsFileNames {
+		fileContent, err := userFS.ReadFile(fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		name, content = fileName, fileContent
+		break
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var err error
+	if path.Ext(name) == ".json" {
+		file := make(map[string]json.RawMessage)
+		if len(content) > 0 {
+			if err := json.Unmarshal(content, &file); err != nil {
+				return fmt.Errorf("invalid settings file %s: %v", name, err)
+			}
+		}
+		for _, key := range keys {
+			if file[key], err = json.Marshal(values[key]); err != nil {
+				return err
+			}
+		}
+		content, err = json.MarshalIndent(file, "", "  ")
+	} else {
+		file := yaml.MapSlice{}
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			return fmt.Errorf("invalid settings file %s: %v", name, err)
+		}
+		for _, key := range keys {
+			found := false
+			for i := range file {
+				if file[i].Key == key {
+					file[i].Value, found = values[key], true
+				}
+			}
+			if !found {
+				file = append(file, yaml.MapItem{Key: key, Value: values[key]})
+			}
+		}
+		content, err = yaml.Marshal(file)
+	}
+	if err != nil {
+		return err
+	}
+	_logger.Debug("Settings file saved", "file", name, "keys", keys)
+	return userFS.WriteFile(name, content)
+}
+
+// settingsKeys returns the keys of Settings fields as defined in json tags
+func settingsKeys() []string {
+	settingsType := reflect.TypeOf(Settings{})
+	keys := make([]string, 0, settingsType.NumField())
+	for i := 0; i < settingsType.NumField(); i++ {
+		if key := settingsKey(settingsType.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// settingsKey returns the key of a Settings field, empty if not loadable (Custom
+// values are set individually)
+func settingsKey(field reflect.StructField) string {
+	if key := strings.Split(field.Tag.Get("json"), ",")[0]; key != "-" && field.Type.Kind() != reflect.Map {
+		return key
+	}
+	return ""
+}
+
+// set sets the field of key from its string form, lists are comma separated
+func (settings *Settings) set(key string, value string) error {
+	if strings.HasPrefix(key, settingsCustomPrefix) {
+		return settings.setCustom(strings.TrimPrefix(key, settingsCustomPrefix), value)
+	}
+	settingsValue := reflect.ValueOf(settings).Elem()
+	for i := 0; i < settingsValue.NumField(); i++ {
+		if settingsKey(settingsValue.Type().Field(i)) == key {
+			return setSettingsValue(settingsValue.Field(i), value)
+		}
+	}
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+// setSettingsValue converts value to the type of field
+func setSettingsValue(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(field.Type().Elem()))
+			}
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------- //
+// Custom settings
+// -------------------------------------------------------------------- //
+
+// DecodeCustom decodes the Custom value of key in target, key can use dots to reach
+// nested values ("server.url") and an empty key decodes the whole Custom section:
+//
+//	var bindings map[string]string
+//	err := settings.DecodeCustom("bindings", &bindings)
+func (settings Settings) DecodeCustom(key string, target interface{}) error {
+	var value interface{} = settings.Custom
+	if key != "" {
+		for _, name := range strings.Split(key, ".") {
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%w: %s%s", ErrSettingNotFound, settingsCustomPrefix, key)
+			}
+			if value, ok = values[name]; !ok {
+				return fmt.Errorf("%w: %s%s", ErrSettingNotFound, settingsCustomPrefix, key)
+			}
+		}
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("invalid setting %s%s: %v", settingsCustomPrefix, key, err)
+	}
+	return nil
+}
+
+// setCustom sets the Custom value of the dotted key, value is parsed as an int, a
+// float or a bool (true or false only), other values are kept as strings
+func (settings *Settings) setCustom(key string, value string) error {
+	names := strings.Split(key, ".")
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("invalid custom setting key %q", key)
+		}
+	}
+	var parsed interface{} = value
+	if i, err := strconv.ParseInt(value, 10, 0); err == nil {
+		parsed = int(i)
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		parsed = f
+	} else if value == "true" || value == "false" {
+		parsed = value == "true"
+	}
+	if settings.Custom == nil {
+		settings.Custom = make(map[string]interface{})
+	}
+	values := settings.Custom
+	for _, name := range names[:len(names)-1] {
+		child, ok := values[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			values[name] = child
+		}
+		values = child
+	}
+	values[names[len(names)-1]] = copyCustom(parsed)
+	return nil
+}
+
+// mergeCustom merges src in dst, nested sections are merged and other values replaced
+func mergeCustom(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		return src
+	}
+	for key, value := range src {
+		srcSection, srcOk := value.(map[string]interface{})
+		dstSection, dstOk := dst[key].(map[string]interface{})
+		if srcOk && dstOk {
+			dst[key] = mergeCustom(dstSection, srcSection)
+		} else {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+// copyCustom deeply copies a Custom value, YAML maps are converted to string keyed
+// maps to be encodable in JSON
+func copyCustom(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return map[string]interface{}(nil)
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyCustom(item)
+		}
+		return copied
+	case map[interface{}]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[fmt.Sprint(key)] = copyCustom(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyCustom(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// clone returns a copy of settings sharing no reference with it
+func (settings Settings) clone() Settings {
+	if settings.AssetPacks != nil {
+		settings.AssetPacks = append([]string(nil), settings.AssetPacks...)
+	}
+	if settings.Actions != nil {
+		actions := make(map[string][]string, len(settings.Actions))
+		for action, bindings := range settings.Actions {
+			actions[action] = append([]string(nil), bindings...)
+		}
+		settings.Actions = actions
+	}
+	settings.Custom = copyCustom(settings.Custom).(map[string]interface{})
+	return settings
 }
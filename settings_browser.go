@@ -0,0 +1,28 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	url "net/url"
+	strings "strings"
+	js "syscall/js"
+)
+
+// querySettings returns the settings given as ?tge.<key>=<value> in the page URL
+func querySettings() map[string]string {
+	overrides := make(map[string]string)
+	query, err := url.ParseQuery(strings.TrimPrefix(js.Global().Get("location").Get("search").String(), "?"))
+	if err != nil {
+		_logger.Warn("Invalid URL query", "error", err)
+		return overrides
+	}
+	for name, values := range query {
+		if strings.HasPrefix(name, settingsArgPrefix) && len(values) > 0 {
+			overrides[strings.TrimPrefix(name, settingsArgPrefix)] = values[len(values)-1]
+		}
+	}
+	return overrides
+}
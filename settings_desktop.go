@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build (darwin || freebsd || linux || windows) && !android && !ios && !js
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package tge
+
+import (
+	flag "flag"
+	fmt "fmt"
+	os "os"
+	reflect "reflect"
+	strings "strings"
+)
+
+// settingsFlag is the command line flag overriding a setting
+type settingsFlag struct {
+	value   string
+	set     bool
+	boolean bool
+}
+
+func (f *settingsFlag) String() string {
+	return f.value
+}
+
+func (f *settingsFlag) Set(value string) error {
+	f.value = value
+	f.set = true
+	return nil
+}
+
+func (f *settingsFlag) IsBoolFlag() bool {
+	return f.boolean
+}
+
+// Command line flags indexed by setting key
+var settingsFlags = make(map[string]*settingsFlag)
+
+// customSettingsFlag is the repeatable -tge.custom key=value flag overriding Custom settings
+type customSettingsFlag map[string]string
+
+func (f customSettingsFlag) String() string {
+	return ""
+}
+
+func (f customSettingsFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[value[:i]] = value[i+1:]
+	return nil
+}
+
+// Name and values of the Custom settings flag
+var customSettingsName, customSettingsFlags = strings.TrimSuffix(settingsCustomPrefix, "."), make(customSettingsFlag)
+
+// init registers the -tge.<key> flags to be accepted when the App parses the command line
+func init() {
+	settingsType := reflect.TypeOf(Settings{})
+	for i := 0; i < settingsType.NumField(); i++ {
+		key := settingsKey(settingsType.Field(i))
+		if key == "" {
+			continue
+		}
+		f := &settingsFlag{boolean: settingsType.Field(i).Type.Kind() == reflect.Bool}
+		settingsFlags[key] = f
+		flag.Var(f, settingsArgPrefix+key, "overrides the "+key+" setting of TGE")
+	}
+	flag.Var(customSettingsFlags, settingsArgPrefix+customSettingsName, "overrides a custom setting of the App (key=value)")
+}
+
+// commandLineSettings returns the settings given as -tge.<key>=<value> on the command
+// line, arguments are read directly if the App did not parse the command line
+func commandLineSettings() map[string]string {
+	if !flag.Parsed() {
+		args := os.Args[1:]
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			name := strings.TrimLeft(arg, "-")
+			if arg == "--" {
+				break
+			} else if !strings.HasPrefix(arg, "-") || !strings.HasPrefix(name, settingsArgPrefix) {
+				continue
+			}
+			name = strings.TrimPrefix(name, settingsArgPrefix)
+			value := ""
+			if j := strings.Index(name, "="); j >= 0 {
+				name, value = name[:j], name[j+1:]
+			} else if f, found := settingsFlags[name]; found && f.boolean {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if name == customSettingsName {
+				if err := customSettingsFlags.Set(value); err != nil {
+					_logger.Warn("Invalid custom setting on command line", "arg", arg, "error", err)
+				}
+			} else if f, found := settingsFlags[name]; found {
+				f.Set(value)
+			} else {
+				_logger.Warn("Unknown setting on command line", "arg", arg)
+			}
+		}
+	}
+
+	overrides := make(map[string]string)
+	for key, f := range settingsFlags {
+		if f.set {
+			overrides[key] = f.value
+		}
+	}
+	for key, value := range customSettingsFlags {
+		overrides[settingsCustomPrefix+key] = value
+	}
+	return overrides
+}
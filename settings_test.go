@@ -0,0 +1,236 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build (darwin || freebsd || linux || windows) && !android && !ios && !js
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package tge
+
+import (
+	fmt "fmt"
+	fs "io/fs"
+	os "os"
+	filepath "path/filepath"
+	reflect "reflect"
+	strings "strings"
+	testing "testing"
+	fstest "testing/fstest"
+)
+
+// missingAssetFS reports missing files as the asset manager of mobile targets
+type missingAssetFS struct{}
+
+func (fsys missingAssetFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("bad asset: %w", fs.ErrNotExist)}
+}
+
+// setUserDataDir moves the user data directory to a temporary one and returns it
+func setUserDataDir(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	t.Setenv("XDG_DATA_HOME", dir)
+	dir, err := userDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadSettingsMissingFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets fs.FS
+	}{
+		{"nil", nil},
+		{"empty", fstest.MapFS{}},
+		{"other files", fstest.MapFS{"config.json": &fstest.MapFile{Data: []byte("{")}}},
+		{"mobile", missingAssetFS{}},
+	}
+	setUserDataDir(t)
+	for _, test := range tests {
+		settings, _, err := loadSettings(test.assets, nil)
+		if err != nil {
+			t.Errorf("%s: loadSettings() failed: %s", test.name, err)
+			continue
+		}
+		if settings.Name != defaultSettings.Name || settings.Width != defaultSettings.Width {
+			t.Errorf("%s: loadSettings() = %+v, want default settings", test.name, settings)
+		}
+	}
+}
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		assets    string
+		user      string
+		userName  string
+		env       map[string]string
+		overrides map[string]string
+		want      Settings
+	}{
+		{
+			name: "defaults",
+			want: Settings{Name: defaultSettings.Name, Width: defaultSettings.Width, Height: defaultSettings.Height},
+		},
+		{
+			name:   "assets",
+			assets: `{"name":"Game","width":100}`,
+			want:   Settings{Name: "Game", Width: 100, Height: defaultSettings.Height},
+		},
+		{
+			name:   "user over assets",
+			assets: `{"name":"Game","width":100,"height":100}`,
+			user:   `{"width":200}`,
+			want:   Settings{Name: "Game", Width: 200, Height: 100},
+		},
+		{
+			name:   "environment over user",
+			assets: `{"name":"Game","width":100}`,
+			user:   `{"width":200,"height":200}`,
+			env:    map[string]string{"TGE_WIDTH": "300"},
+			want:   Settings{Name: "Game", Width: 300, Height: 200},
+		},
+		{
+			name:      "overrides over environment",
+			assets:    `{"name":"Game","width":100}`,
+			user:      `{"width":200}`,
+			env:       map[string]string{"TGE_WIDTH": "300", "TGE_HEIGHT": "300"},
+			overrides: map[string]string{"width": "400"},
+			want:      Settings{Name: "Game", Width: 400, Height: 300},
+		},
+		{
+			name:   "name not read from user",
+			assets: `{"name":"Game"}`,
+			user:   `{"name":"Other","width":200}`,
+			want:   Settings{Name: "Game", Width: 200, Height: defaultSettings.Height},
+		},
+		{
+			name:     "user located with environment name",
+			assets:   `{"name":"Game"}`,
+			user:     `{"width":200}`,
+			userName: "Env",
+			env:      map[string]string{"TGE_NAME": "Env"},
+			want:     Settings{Name: "Env", Width: 200, Height: defaultSettings.Height},
+		},
+		{
+			name:   "custom merged",
+			assets: `{"custom":{"server":{"url":"a","port":1},"mode":"easy"}}`,
+			user:   `{"custom":{"server":{"url":"b"}}}`,
+			env:    map[string]string{"TGE_CUSTOM_SERVER__PORT": "2"},
+			want: Settings{Name: defaultSettings.Name, Width: defaultSettings.Width, Height: defaultSettings.Height,
+				Custom: map[string]interface{}{"server": map[string]interface{}{"url": "b", "port": 2}, "mode": "easy"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := setUserDataDir(t)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			assets := fstest.MapFS{}
+			if test.assets != "" {
+				assets["settings.json"] = &fstest.MapFile{Data: []byte(test.assets)}
+			}
+			if test.user != "" {
+				userName := test.userName
+				if userName == "" {
+					userName = test.want.Name
+				}
+				userDir := filepath.Join(dir, appDirName(userName), userFSDirName)
+				if err := os.MkdirAll(userDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(userDir, "settings.json"), []byte(test.user), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			settings, userFS, err := loadSettings(assets, test.overrides)
+			if err != nil {
+				t.Fatalf("loadSettings() failed: %s", err)
+			}
+			if userFS == nil {
+				t.Fatal("loadSettings() returned no UserFS")
+			}
+			if settings.Name != test.want.Name || settings.Width != test.want.Width || settings.Height != test.want.Height {
+				t.Errorf("loadSettings() = {Name:%s Width:%d Height:%d}, want {Name:%s Width:%d Height:%d}",
+					settings.Name, settings.Width, settings.Height, test.want.Name, test.want.Width, test.want.Height)
+			}
+			if test.want.Custom != nil && !reflect.DeepEqual(settings.Custom, test.want.Custom) {
+				t.Errorf("loadSettings().Custom = %v, want %v", settings.Custom, test.want.Custom)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		assets    string
+		env       map[string]string
+		overrides map[string]string
+	}{
+		{name: "file", assets: `{"width":`},
+		{name: "environment", env: map[string]string{"TGE_WIDTH": "wide"}},
+		{name: "override", overrides: map[string]string{"fullscreen": "maybe"}},
+		{name: "unknown key", overrides: map[string]string{"depth": "32"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setUserDataDir(t)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			assets := fstest.MapFS{}
+			if test.assets != "" {
+				assets["settings.json"] = &fstest.MapFile{Data: []byte(test.assets)}
+			}
+			if _, _, err := loadSettings(assets, test.overrides); err == nil {
+				t.Error("loadSettings() should fail")
+			}
+		})
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(settings *Settings)
+		problems []string
+	}{
+		{"default", func(settings *Settings) {}, nil},
+		{"width", func(settings *Settings) { settings.Width = 0 }, []string{"width"}},
+		{"height", func(settings *Settings) { settings.Height = -1 }, []string{"height"}},
+		{"event mask", func(settings *Settings) { settings.EventMask = 0x10000 }, []string{"event_mask"}},
+		{"asset cache", func(settings *Settings) { settings.AssetCacheSize = -1 }, []string{"asset_cache_size"}},
+		{"quota", func(settings *Settings) { settings.UserFSQuota = -1 }, []string{"user_fs_quota"}},
+		{"log level", func(settings *Settings) { settings.LogLevel = LogLevelNone + 1 }, []string{"log_level"}},
+		{"action", func(settings *Settings) { settings.Actions = map[string][]string{"jump": {"key:nope"}} }, []string{"actions.jump"}},
+		{"record", func(settings *Settings) { settings.Record, settings.Replay = "a.rec", "a.rec" }, []string{"record"}},
+		{"several", func(settings *Settings) { settings.Width, settings.Height = 0, 0 }, []string{"width", "height"}},
+	}
+	for _, test := range tests {
+		settings := defaultSettings.clone()
+		test.change(&settings)
+		err := settings.Validate()
+		if len(test.problems) == 0 {
+			if err != nil {
+				t.Errorf("%s: Validate() failed: %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: Validate() should fail", test.name)
+			continue
+		}
+		for _, problem := range test.problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%s: Validate() = %q, want %s problem", test.name, err, problem)
+			}
+		}
+	}
+}
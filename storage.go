@@ -0,0 +1,137 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	errors "errors"
+	sort "sort"
+	strings "strings"
+	sync "sync"
+)
+
+// ErrStorageUnavailable is returned by Commit() when the storage could not be loaded,
+// the stored values are then protected from being overwritten
+var ErrStorageUnavailable = errors.New("storage unavailable")
+
+// Storage is a persistent key-value store of the App, it allows to save settings or
+// progress the same way on all targets. Changes made with Set() and Delete() are
+// visible immediately but are only persisted atomically by Commit().
+type Storage struct {
+	mutex     sync.RWMutex
+	backend   storageBackend
+	loadErr   error
+	values    map[string][]byte
+	committed map[string][]byte
+}
+
+// storageBackend persists all the values of a Storage at once, save must replace
+// the stored values atomically
+type storageBackend interface {
+	load() (map[string][]byte, error)
+	save(values map[string][]byte) error
+}
+
+// newStorage creates a Storage on backend and loads its values
+func newStorage(backend storageBackend) *Storage {
+	storage := &Storage{backend: backend}
+	values, err := backend.load()
+	if err != nil {
+		_logger.Error("Failed to load storage", "error", err)
+		storage.loadErr = err
+		values = nil
+	}
+	if values == nil {
+		values = make(map[string][]byte)
+	}
+	storage.committed = values
+	storage.values = copyValues(values)
+	return storage
+}
+
+func (core *runtimeCore) GetStorage() *Storage {
+	return core.storage
+}
+
+// Get returns the value of key and true if found
+func (storage *Storage) Get(key string) ([]byte, bool) {
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+	value, found := storage.values[key]
+	if !found {
+		return nil, false
+	}
+	return append([]byte(nil), value...), true
+}
+
+// Set sets the value of key until next Commit() or Rollback()
+func (storage *Storage) Set(key string, value []byte) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	storage.values[key] = append([]byte(nil), value...)
+}
+
+// Delete removes key until next Commit() or Rollback()
+func (storage *Storage) Delete(key string) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	delete(storage.values, key)
+}
+
+// List returns the sorted keys starting with prefix, all keys are returned if
+// prefix is empty
+func (storage *Storage) List(prefix string) []string {
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+	keys := []string{}
+	for key := range storage.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Commit persists all the changes at once, stored values are left untouched on error
+func (storage *Storage) Commit() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	if storage.loadErr != nil {
+		return ErrStorageUnavailable
+	}
+	if err := storage.backend.save(storage.values); err != nil {
+		return err
+	}
+	storage.committed = copyValues(storage.values)
+	return nil
+}
+
+// Rollback discards the changes made since the last Commit()
+func (storage *Storage) Rollback() {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	storage.values = copyValues(storage.committed)
+}
+
+// copyValues returns a shallow copy of values, contents are never modified in place
+func copyValues(values map[string][]byte) map[string][]byte {
+	copied := make(map[string][]byte, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+// appDirName converts the App name to a name usable as directory or key prefix
+func appDirName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "tge"
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	json "encoding/json"
+	fmt "fmt"
+	js "syscall/js"
+)
+
+// Prefix of the localStorage key holding the values of the Storage
+const storageKeyPrefix = "tge.storage."
+
+// browserStorageBackend stores all values as a single JSON item of localStorage,
+// each commit replaces the item at once
+type browserStorageBackend struct {
+	key string
+}
+
+// newStorageBackend stores values in the localStorage of the origin
+func newStorageBackend(settings Settings) storageBackend {
+	return &browserStorageBackend{key: storageKeyPrefix + appDirName(settings.Name)}
+}
+
+func (backend *browserStorageBackend) load() (values map[string][]byte, err error) {
+	defer recoverJSError(&err)
+	item := js.Global().Get("localStorage").Call("getItem", backend.key)
+	if item.IsNull() || item.IsUndefined() {
+		return nil, nil
+	}
+	values = make(map[string][]byte)
+	if err := json.Unmarshal([]byte(item.String()), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (backend *browserStorageBackend) save(values map[string][]byte) (err error) {
+	defer recoverJSError(&err)
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	js.Global().Get("localStorage").Call("setItem", backend.key, string(content))
+	return nil
+}
+
+// recoverJSError converts JS exceptions (quota exceeded, storage disabled ...) to errors
+func recoverJSError(err *error) {
+	if r := recover(); r != nil {
+		if jsErr, ok := r.(js.Error); ok {
+			*err = jsErr
+		} else {
+			*err = fmt.Errorf("%v", r)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build (darwin || freebsd || linux || windows) && !android && !ios && !js
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package tge
+
+import (
+	os "os"
+	filepath "path/filepath"
+)
+
+// newStorageBackend stores values in the App folder of the OS config directory
+func newStorageBackend(settings Settings) storageBackend {
+	dir, err := os.UserConfigDir()
+	return newFileStorageBackend(filepath.Join(dir, appDirName(settings.Name)), err)
+}
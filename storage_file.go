@@ -0,0 +1,80 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !js
+// +build !js
+
+package tge
+
+import (
+	json "encoding/json"
+	os "os"
+	filepath "path/filepath"
+)
+
+// Name of the file holding the values of the Storage
+const storageFileName = "storage.json"
+
+// fileStorageBackend stores values in a JSON file, the file is replaced atomically
+// by writing a temporary file first
+type fileStorageBackend struct {
+	path string
+	err  error
+}
+
+// newFileStorageBackend creates a backend storing values in dir, err is returned
+// at loading if dir could not be found
+func newFileStorageBackend(dir string, err error) storageBackend {
+	return &fileStorageBackend{path: filepath.Join(dir, storageFileName), err: err}
+}
+
+func (backend *fileStorageBackend) load() (map[string][]byte, error) {
+	if backend.err != nil {
+		return nil, backend.err
+	}
+	content, err := os.ReadFile(backend.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte)
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	_logger.Debug("Storage loaded", "path", backend.path, "keys", len(values))
+	return values, nil
+}
+
+func (backend *fileStorageBackend) save(values map[string][]byte) error {
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(backend.path, content)
+}
+
+// writeFileAtomic replaces the file at path by content, a temporary file is written
+// first in the same directory and renamed once synced, parents are created if needed
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(content); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
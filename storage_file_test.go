@@ -0,0 +1,37 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !js
+// +build !js
+
+package tge
+
+import (
+	os "os"
+	filepath "path/filepath"
+	testing "testing"
+)
+
+func TestFileStorageBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	storage := newStorage(newFileStorageBackend(dir, nil))
+	storage.Set("score", []byte{0, 1, 255})
+	if err := storage.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %s", err)
+	}
+
+	reloaded := newStorage(newFileStorageBackend(dir, nil))
+	if value, found := reloaded.Get("score"); !found || string(value) != string([]byte{0, 1, 255}) {
+		t.Errorf("Get(score) = %v, %v after reload, want [0 1 255]", value, found)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != storageFileName {
+		t.Errorf("storage directory has %v, want only %s", entries, storageFileName)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, storageFileName), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := newStorage(newFileStorageBackend(dir, nil)).Commit(); err != ErrStorageUnavailable {
+		t.Errorf("Commit() of a corrupted storage = %v, want ErrStorageUnavailable", err)
+	}
+}
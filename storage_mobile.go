@@ -0,0 +1,27 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android || ios
+// +build android ios
+
+package tge
+
+import (
+	os "os"
+	filepath "path/filepath"
+	runtime "runtime"
+)
+
+// newStorageBackend stores values in the files directory of the App
+func newStorageBackend(settings Settings) storageBackend {
+	dir, err := mobileFilesDir()
+	return newFileStorageBackend(dir, err)
+}
+
+// mobileFilesDir returns the private files directory of the App, the cache directory
+// is given as TMPDIR on android and the home directory is the sandbox on iOS
+func mobileFilesDir() (string, error) {
+	if runtime.GOOS == "android" {
+		return filepath.Join(filepath.Dir(os.TempDir()), "files"), nil
+	}
+	return os.UserConfigDir()
+}
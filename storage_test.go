@@ -0,0 +1,165 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	errors "errors"
+	reflect "reflect"
+	strings "strings"
+	testing "testing"
+)
+
+// memoryStorageBackend keeps the saved values in memory, save fails if err is set
+type memoryStorageBackend struct {
+	values  map[string][]byte
+	loadErr error
+	saveErr error
+	saves   int
+}
+
+func (backend *memoryStorageBackend) load() (map[string][]byte, error) {
+	return copyValues(backend.values), backend.loadErr
+}
+
+func (backend *memoryStorageBackend) save(values map[string][]byte) error {
+	if backend.saveErr != nil {
+		return backend.saveErr
+	}
+	backend.values = copyValues(values)
+	backend.saves++
+	return nil
+}
+
+func TestStorage(t *testing.T) {
+	errSave := errors.New("disk full")
+	tests := []struct {
+		name    string
+		stored  map[string]string
+		ops     string
+		saveErr error
+		values  map[string]string
+		saved   map[string]string
+		err     error
+	}{
+		{"loaded", map[string]string{"a": "1"}, "", nil,
+			map[string]string{"a": "1"}, map[string]string{"a": "1"}, nil},
+		{"set not saved", nil, "set a 1", nil,
+			map[string]string{"a": "1"}, map[string]string{}, nil},
+		{"commit", map[string]string{"a": "1", "b": "2"}, "set a 3, delete b, commit", nil,
+			map[string]string{"a": "3"}, map[string]string{"a": "3"}, nil},
+		{"rollback", map[string]string{"a": "1"}, "set a 2, set b 2, delete a, rollback", nil,
+			map[string]string{"a": "1"}, map[string]string{"a": "1"}, nil},
+		{"rollback to last commit", nil, "set a 1, commit, set a 2, rollback", nil,
+			map[string]string{"a": "1"}, map[string]string{"a": "1"}, nil},
+		{"failed commit", map[string]string{"a": "1"}, "set a 2, commit", errSave,
+			map[string]string{"a": "2"}, map[string]string{"a": "1"}, errSave},
+		{"rollback after failed commit", map[string]string{"a": "1"}, "set a 2, commit, rollback", errSave,
+			map[string]string{"a": "1"}, map[string]string{"a": "1"}, errSave},
+	}
+	for _, test := range tests {
+		backend := &memoryStorageBackend{values: make(map[string][]byte), saveErr: test.saveErr}
+		for key, value := range test.stored {
+			backend.values[key] = []byte(value)
+		}
+		storage := newStorage(backend)
+		var err error
+		for _, op := range strings.Split(test.ops, ", ") {
+			fields := strings.Fields(op)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == "set":
+				storage.Set(fields[1], []byte(fields[2]))
+			case fields[0] == "delete":
+				storage.Delete(fields[1])
+			case fields[0] == "commit":
+				err = storage.Commit()
+			case fields[0] == "rollback":
+				storage.Rollback()
+			}
+		}
+		if err != test.err {
+			t.Errorf("%s: Commit() = %v, want %v", test.name, err, test.err)
+		}
+		values := make(map[string]string)
+		for _, key := range storage.List("") {
+			value, _ := storage.Get(key)
+			values[key] = string(value)
+		}
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%s: values %v, want %v", test.name, values, test.values)
+		}
+		saved := make(map[string]string)
+		for key, value := range backend.values {
+			saved[key] = string(value)
+		}
+		if !reflect.DeepEqual(saved, test.saved) {
+			t.Errorf("%s: saved %v, want %v", test.name, saved, test.saved)
+		}
+	}
+}
+
+func TestStorageUnavailable(t *testing.T) {
+	backend := &memoryStorageBackend{values: map[string][]byte{"a": []byte("1")}, loadErr: errors.New("corrupted")}
+	storage := newStorage(backend)
+	if _, found := storage.Get("a"); found {
+		t.Error("Get() should not find values of a storage failed to load")
+	}
+	storage.Set("b", []byte("2"))
+	if err := storage.Commit(); err != ErrStorageUnavailable {
+		t.Errorf("Commit() = %v, want ErrStorageUnavailable", err)
+	}
+	if backend.saves != 0 {
+		t.Error("Commit() should not overwrite a storage failed to load")
+	}
+}
+
+func TestStorageCopies(t *testing.T) {
+	storage := newStorage(&memoryStorageBackend{})
+	value := []byte("value")
+	storage.Set("key", value)
+	value[0] = 'X'
+	got, _ := storage.Get("key")
+	got[1] = 'X'
+	if again, _ := storage.Get("key"); string(again) != "value" {
+		t.Errorf("Get() = %s after modifications, want value", again)
+	}
+}
+
+func TestStorageList(t *testing.T) {
+	storage := newStorage(&memoryStorageBackend{})
+	for _, key := range []string{"save.2", "options", "save.1"} {
+		storage.Set(key, nil)
+	}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"options", "save.1", "save.2"}},
+		{"save.", []string{"save.1", "save.2"}},
+		{"none", []string{}},
+	}
+	for _, test := range tests {
+		if got := storage.List(test.prefix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("List(%q) = %v, want %v", test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestAppDirName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"My Game", "My Game"},
+		{" My Game ", "My Game"},
+		{"a/b\\c:d", "a_b_c_d"},
+		{"", "tge"},
+		{"..", "tge"},
+		{"a\x01b", "a_b"},
+	}
+	for _, test := range tests {
+		if got := appDirName(test.name); got != test.want {
+			t.Errorf("appDirName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -6,4 +6,4 @@
 // license that can be found in the LICENSE file.
 (()=>{if("undefined"!=typeof global);else if("undefined"!=typeof window)window.global=window;else if("undefined"!=typeof self)self.global=self;else throw new Error("cannot export Go (neither global, window nor self is defined)");const a=global.process&&"node"===global.process.title;if(a){global.require=require,global.fs=require("fs");const a=require("crypto");global.crypto={getRandomValues(c){a.randomFillSync(c)}},global.performance={now(){const[a,b]=process.hrtime();return 1e3*a+b/1e6}};const b=require("util");global.TextEncoder=b.TextEncoder,global.TextDecoder=b.TextDecoder}else{let a="";global.fs={constants:{O_WRONLY:-1,O_RDWR:-1,O_CREAT:-1,O_TRUNC:-1,O_APPEND:-1,O_EXCL:-1},writeSync(b,d){a+=c.decode(d);const e=a.lastIndexOf("\n");return-1!=e&&(console.log(a.substr(0,e)),a=a.substr(e+1)),d.length},write(a,b,c,d,e,f){if(0!==c||d!==b.length||null!==e)throw new Error("not implemented");const g=this.writeSync(a,b);f(null,g)},open(a,b,c,d){const e=new Error("not implemented");e.code="ENOSYS",d(e)},read(a,b,c,d,e,f){const g=new Error("not implemented");g.code="ENOSYS",f(g)},fsync(a,b){b(null)}}}const b=new TextEncoder("utf-8"),c=new TextDecoder("utf-8");if(global.Go=class{constructor(){this.argv=["js"],this.env={},this.exit=a=>{0!==a&&console.warn("exit code:",a)},this._exitPromise=new Promise(a=>{this._resolveExitPromise=a}),this._pendingEvent=null,this._scheduledTimeouts=new Map,this._nextCallbackTimeoutID=1;const a=()=>new DataView(this._inst.exports.mem.buffer),d=(b,c)=>{a().setUint32(b+0,c,!0),a().setUint32(b+4,Math.floor(c/4294967296),!0)},e=b=>{const c=a().getUint32(b+0,!0),d=a().getInt32(b+4,!0);return c+4294967296*d},f=b=>{const c=a().getFloat64(b,!0);if(0!==c){if(!isNaN(c))return c;const d=a().getUint32(b,!0);return this._values[d]}},g=(b,c)=>{const d=2146959360;if("number"==typeof c)return isNaN(c)?(a().setUint32(b+4,2146959360,!0),void a().setUint32(b,0,!0)):0===c?(a().setUint32(b+4,2146959360,!0),void a().setUint32(b,1,!0)):void a().setFloat64(b,c,!0);switch(c){case void 0:return void a().setFloat64(b,0,!0);case null:return a().setUint32(b+4,d,!0),void a().setUint32(b,2,!0);case!0:return a().setUint32(b+4,d,!0),void a().setUint32(b,3,!0);case!1:return a().setUint32(b+4,d,!0),void a().setUint32(b,4,!0);}let e=this._refs.get(c);void 0===e&&(e=this._values.length,this._values.push(c),this._refs.set(c,e));let f=0;switch(typeof c){case"string":f=1;break;case"symbol":f=2;break;case"function":f=3;}a().setUint32(b+4,2146959360|f,!0),a().setUint32(b,e,!0)},h=a=>{const b=e(a+0),c=e(a+8);return new Uint8Array(this._inst.exports.mem.buffer,b,c)},i=b=>{const c=e(b+0),d=e(b+8),g=Array(d);for(let a=0;a<d;a++)g[a]=f(c+8*a);return g},j=a=>{const b=e(a+0),d=e(a+8);return c.decode(new DataView(this._inst.exports.mem.buffer,b,d))},k=Date.now()-performance.now();this.importObject={go:{"runtime.wasmExit":b=>{const c=a().getInt32(b+8,!0);this.exited=!0,delete this._inst,delete this._values,delete this._refs,this.exit(c)},"runtime.wasmWrite":b=>{const c=e(b+8),d=e(b+16),f=a().getInt32(b+24,!0);fs.writeSync(c,new Uint8Array(this._inst.exports.mem.buffer,d,f))},"runtime.nanotime":a=>{d(a+8,1e6*(k+performance.now()))},"runtime.walltime":b=>{const c=new Date().getTime();d(b+8,c/1e3),a().setInt32(b+16,1e6*(c%1e3),!0)},"runtime.scheduleTimeoutEvent":b=>{const c=this._nextCallbackTimeoutID;this._nextCallbackTimeoutID++,this._scheduledTimeouts.set(c,setTimeout(()=>{this._resume()},e(b+8)+1)),a().setInt32(b+16,c,!0)},"runtime.clearTimeoutEvent":b=>{const c=a().getInt32(b+8,!0);clearTimeout(this._scheduledTimeouts.get(c)),this._scheduledTimeouts.delete(c)},"runtime.getRandomData":a=>{crypto.getRandomValues(h(a+8))},"syscall/js.stringVal":a=>{g(a+24,j(a+8))},"syscall/js.valueGet":a=>{const b=Reflect.get(f(a+8),j(a+16));a=this._inst.exports.getsp(),g(a+32,b)},"syscall/js.valueSet":a=>{Reflect.set(f(a+8),j(a+16),f(a+32))},"syscall/js.valueIndex":a=>{g(a+24,Reflect.get(f(a+8),e(a+16)))},"syscall/js.valueSetIndex":a=>{Reflect.set(f(a+8),e(a+16),f(a+24))},"syscall/js.valueCall":b=>{try{const c=f(b+8),d=Reflect.get(c,j(b+16)),e=i(b+32),h=Reflect.apply(d,c,e);b=this._inst.exports.getsp(),g(b+56,h),a().setUint8(b+64,1)}catch(c){g(b+56,c),a().setUint8(b+64,0)}},"syscall/js.valueInvoke":b=>{try{const c=f(b+8),d=i(b+16),e=Reflect.apply(c,void 0,d);b=this._inst.exports.getsp(),g(b+40,e),a().setUint8(b+48,1)}catch(c){g(b+40,c),a().setUint8(b+48,0)}},"syscall/js.valueNew":b=>{try{const c=f(b+8),d=i(b+16),e=Reflect.construct(c,d);b=this._inst.exports.getsp(),g(b+40,e),a().setUint8(b+48,1)}catch(c){g(b+40,c),a().setUint8(b+48,0)}},"syscall/js.valueLength":a=>{d(a+16,parseInt(f(a+8).length))},"syscall/js.valuePrepareString":a=>{const c=b.encode(f(a+8)+"");g(a+16,c),d(a+24,c.length)},"syscall/js.valueLoadString":a=>{const b=f(a+8);h(a+16).set(b)},"syscall/js.valueInstanceOf":b=>{a().setUint8(b+24,f(b+8)instanceof f(b+16))},debug:a=>{console.log(a)}}}}async run(a){this._inst=a,this._values=[NaN,0,null,!0,!1,global,this._inst.exports.mem,this],this._refs=new Map,this.exited=!1;const c=new DataView(this._inst.exports.mem.buffer);let d=4096;const e=a=>{let e=d;return new Uint8Array(c.buffer,d,a.length+1).set(b.encode(a+"\0")),d+=a.length+(8-a.length%8),e},f=this.argv.length,g=[];this.argv.forEach(a=>{g.push(e(a))});const h=Object.keys(this.env).sort();g.push(h.length),h.forEach(a=>{g.push(e(`${a}=${this.env[a]}`))});const i=d;g.forEach(a=>{c.setUint32(d,a,!0),c.setUint32(d+4,0,!0),d+=8}),this._inst.exports.run(f,i),this.exited&&this._resolveExitPromise(),await this._exitPromise}_resume(){if(this.exited)throw new Error("Go program has already exited");this._inst.exports.resume(),this.exited&&this._resolveExitPromise()}_makeFuncWrapper(a){const b=this;return function(){const c={id:a,this:this,args:arguments};return b._pendingEvent=c,b._resume(),c.result}}},a){3>process.argv.length&&(process.stderr.write("usage: go_js_wasm_exec [wasm binary] [arguments]\n"),process.exit(1));const a=new Go;a.argv=process.argv.slice(2),a.env=Object.assign({TMPDIR:require("os").tmpdir()},process.env),a.exit=process.exit,WebAssembly.instantiate(fs.readFileSync(process.argv[2]),a.importObject).then(b=>(process.on("exit",b=>{0!==b||a.exited||(a._pendingEvent={id:0},a._resume())}),a.run(b.instance))).catch(a=>{throw a})}})();
 // TGE Tooling JS
-(()=>{if("undefined"!=typeof window)window.global=window;else if("undefined"!=typeof self)self.global=self;else throw new Error("cannot start TGE (neither window nor self is defined)");let a=document.getElementById("canvas");if(!a)throw new Error("Canvas element not found (must be #canvas)");let b=!1,c={};global.tge={init(){return a.classList.remove("stop"),a.classList.add("start"),a.oncontextmenu=function(a){a.preventDefault()},a.focus(),a},setFullscreen(c){b=c,c?a.classList.add("fullscreen"):a.classList.remove("fullscreen"),a.setAttribute("width",a.clientWidth),a.setAttribute("height",a.clientHeight)},resize(c,d){b||(a.style.width=c+"px",a.style.height=d+"px"),a.setAttribute("width",a.clientWidth),a.setAttribute("height",a.clientHeight)},getAssetSize(a,b){fetch("./assets/"+a).then(a=>{if(a.ok)return a.arrayBuffer();throw new Error(a.statusText)}).then(d=>{if(d)c[a]=new Uint8Array(d);else throw new Error("empty content");b(d.byteLength,null)}).catch(a=>{b(null,a)})},loadAsset(a,b,d){c[a]?(b.set(c[a]),delete c[a],d(null)):d("empty content")},createAudioBuffer(a,b,c){fetch("./assets/"+b).then(a=>{if(a.ok)return a.arrayBuffer();throw new Error(a.statusText)}).then(b=>a.decodeAudioData(b)).then(a=>{c(a,null)}).catch(a=>{c(null,a)})},createMediaAudioElement(a,b){let c=document.createElement("audio");return c.setAttribute("src","./assets/"+b),document.body.appendChild(c),{htmlElement:c,mediaAudioElement:a.createMediaElementSource(c)}},stop(){a.classList.remove("start"),a.classList.add("stop")},showError(a){console.error(a)}},window.onload=function(){window.go=new Go,window.AudioContext=window.AudioContext||window.webkitAudioContext,WebAssembly.instantiateStreaming?WebAssembly.instantiateStreaming(fetch("main.wasm"),window.go.importObject).then(a=>{window.go.run(a.instance)}).catch(a=>{tge.showError(a)}):fetch("main.wasm").then(a=>a.arrayBuffer()).then(a=>WebAssembly.instantiate(a,window.go.importObject)).then(a=>{window.go.run(a.instance)}).catch(a=>{tge.showError(a)})}})();
\ No newline at end of file
+(()=>{if("undefined"!=typeof window)window.global=window;else if("undefined"!=typeof self)self.global=self;else throw new Error("cannot start TGE (neither window nor self is defined)");let a=document.getElementById("canvas");if(!a)throw new Error("Canvas element not found (must be #canvas)");let b=!1;let k=document.createElement("input");k.classList.add("keyboard"),k.setAttribute("autocomplete","off"),k.setAttribute("autocapitalize","off"),k.setAttribute("spellcheck","false");let c=null;function d(a,b){return null===c&&(c=new Promise((a,b)=>{let d=indexedDB.open("tge-userfs",1);d.onupgradeneeded=()=>d.result.createObjectStore("files"),d.onsuccess=()=>a(d.result),d.onerror=()=>b(d.error)})),c.then(c=>new Promise((d,e)=>{let f=c.transaction("files",a),g=null,h=null;f.oncomplete=()=>d(g),f.onerror=()=>e(f.error),f.onabort=()=>e(h||f.error||new Error("transaction aborted")),b(f.objectStore("files"),a=>{g=a},a=>{h=a,f.abort()})}))}let m=new Map;function n(a,b){let c=b.then(a=>a.arrayBuffer());return m.set(a,c),c.catch(()=>m.delete(a)),c}function e(a,b){a.then(a=>{b(a,null)}).catch(a=>{b(null,a.toString())})}global.tge={init(){return a.classList.remove("stop"),a.classList.add("start"),a.oncontextmenu=function(a){a.preventDefault()},a.focus(),document.body.appendChild(k),a},getKeyboard(){return k},showKeyboard(b){k.setAttribute("type","number"===b?"text":b),k.setAttribute("inputmode","number"===b?"decimal":"email"===b?"email":"text"),k.value="",k.focus()},hideKeyboard(){document.activeElement===k&&(k.blur(),a.focus())},setFullscreen(c){b=c,c?a.classList.add("fullscreen"):a.classList.remove("fullscreen"),a.setAttribute("width",a.clientWidth),a.setAttribute("height",a.clientHeight)},resize(c,d){b||(a.style.width=c+"px",a.style.height=d+"px"),a.setAttribute("width",a.clientWidth),a.setAttribute("height",a.clientHeight)},getAssetSize(a,b){fetch("./assets/"+a,{method:"HEAD"}).then(c=>{if(!c.ok)throw new Error(c.status+" "+c.statusText);let d=c.headers.get("Content-Length");return null!==d?parseInt(d):n(a,fetch("./assets/"+a).then(a=>{if(!a.ok)throw new Error(a.status+" "+a.statusText);return a})).then(a=>a.byteLength)}).then(a=>{b(a,null)}).catch(a=>{b(null,a.toString())})},loadAssetRange(a,b,c,d){let f=m.get(a);(void 0!==f?f.then(a=>({full:a})):fetch("./assets/"+a,{headers:{Range:"bytes="+b+"-"+(c-1)}}).then(b=>{if(!b.ok)throw new Error(b.statusText);return 206===b.status?b.arrayBuffer().then(a=>({partial:a})):n(a,Promise.resolve(b)).then(a=>({full:a}))})).then(a=>void 0!==a.partial?new Uint8Array(a.partial):new Uint8Array(a.full,b,Math.max(0,Math.min(c,a.full.byteLength)-b))).then(a=>{d(a,null)}).catch(b=>{m.delete(a),d(null,b.toString())})},releaseAsset(a){m.delete(a)},userFSGet(a,b){e(d("readonly",(b,c)=>{let d=b.get(a);d.onsuccess=()=>c(void 0===d.result?null:d.result)}),b)},userFSPut(a,b,c){e(d("readwrite",c=>{c.put({data:b,modTime:Date.now()},a)}),c)},userFSDelete(a,b){e(d("readwrite",b=>{b.delete(a)}),b)},userFSRename(a,b,c){e(d("readwrite",(c,d,e)=>{let f=c.get(a);f.onsuccess=()=>{void 0===f.result?e(new Error("file does not exist")):(c.put(f.result,b),c.delete(a))}}),c)},userFSList(a,b){e(d("readonly",(b,c)=>{let d=[],e=b.openCursor(IDBKeyRange.bound(a,a+"\uffff"));e.onsuccess=()=>{let a=e.result;a?(d.push({key:a.key,size:a.value.data.byteLength,modTime:a.value.modTime}),a.continue()):c(d)}}),b)},createAudioBuffer(a,b,c){fetch("./assets/"+b).then(a=>{if(a.ok)return a.arrayBuffer();throw new Error(a.statusText)}).then(b=>a.decodeAudioData(b)).then(a=>{c(a,null)}).catch(a=>{c(null,a)})},createMediaAudioElement(a,b){let c=document.createElement("audio");return c.setAttribute("src","./assets/"+b),document.body.appendChild(c),{htmlElement:c,mediaAudioElement:a.createMediaElementSource(c)}},stop(){a.classList.remove("start"),a.classList.add("stop")},showError(a){console.error(a)}},window.onload=function(){window.go=new Go,window.AudioContext=window.AudioContext||window.webkitAudioContext,WebAssembly.instantiateStreaming?WebAssembly.instantiateStreaming(fetch("main.wasm"),window.go.importObject).then(a=>{window.go.run(a.instance)}).catch(a=>{tge.showError(a)}):fetch("main.wasm").then(a=>a.arrayBuffer()).then(a=>WebAssembly.instantiate(a,window.go.importObject)).then(a=>{window.go.run(a.instance)}).catch(a=>{tge.showError(a)})}})();
\ No newline at end of file
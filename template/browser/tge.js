@@ -14,7 +14,62 @@
     }
 
     let fullscreen = false
-    let assetsMap = {}
+
+    // Hidden input focused to show the virtual keyboard
+    let keyboardEl = document.createElement('input');
+    keyboardEl.classList.add('keyboard');
+    keyboardEl.setAttribute('autocomplete', 'off');
+    keyboardEl.setAttribute('autocapitalize', 'off');
+    keyboardEl.setAttribute('spellcheck', 'false');
+
+    let userDB = null
+
+    // userTransaction runs fn in a transaction on user files, the returned promise is resolved
+    // with the value given to done() once the transaction is complete
+    function userTransaction(mode, fn) {
+        if (userDB === null) {
+            userDB = new Promise((resolve, reject) => {
+                let request = indexedDB.open('tge-userfs', 1)
+                request.onupgradeneeded = () => request.result.createObjectStore('files')
+                request.onsuccess = () => resolve(request.result)
+                request.onerror = () => reject(request.error)
+            })
+        }
+        return userDB.then((db) => new Promise((resolve, reject) => {
+            let transaction = db.transaction('files', mode)
+            let result = null
+            let failure = null
+            transaction.oncomplete = () => resolve(result)
+            transaction.onerror = () => reject(transaction.error)
+            transaction.onabort = () => reject(failure || transaction.error || new Error('transaction aborted'))
+            fn(transaction.objectStore('files'), (value) => {
+                result = value
+            }, (error) => {
+                failure = error
+                transaction.abort()
+            })
+        }))
+    }
+
+    // Full contents of assets whose server ignores ranges or sends no length, they are
+    // kept until the asset is closed to avoid downloading them for each range
+    let fullAssets = new Map()
+
+    function fullAsset(path, response) {
+        let content = response.then((response) => response.arrayBuffer())
+        fullAssets.set(path, content)
+        content.catch(() => fullAssets.delete(path))
+        return content
+    }
+
+    function userCallback(promise, callback) {
+        promise.then((result) => {
+            callback(result, null)
+        })
+        .catch((error) => {
+            callback(null, error.toString())
+        });
+    }
 
     global.tge = {
         init() {
@@ -22,9 +77,29 @@
             canvasEl.classList.add('start');
             canvasEl.oncontextmenu = function (e) {e.preventDefault();};
             canvasEl.focus()
+            document.body.appendChild(keyboardEl);
             return canvasEl;
         },
 
+        getKeyboard() {
+            return keyboardEl;
+        },
+
+        showKeyboard(type) {
+            // Number inputs reject partial values, the numeric layout is selected by inputmode
+            keyboardEl.setAttribute('type', type === 'number' ? 'text' : type);
+            keyboardEl.setAttribute('inputmode', type === 'number' ? 'decimal' : type === 'email' ? 'email' : 'text');
+            keyboardEl.value = '';
+            keyboardEl.focus();
+        },
+
+        hideKeyboard() {
+            if (document.activeElement === keyboardEl) {
+                keyboardEl.blur();
+                canvasEl.focus();
+            }
+        },
+
         setFullscreen(enabled) {
             fullscreen = enabled
             if (enabled) {
@@ -45,35 +120,114 @@
             canvasEl.setAttribute('height', canvasEl.clientHeight);
         },
 
-        getAssetSize(path, callback) {            
-            fetch('./assets/' + path).then((response) => {
-                if(response.ok) {                    
-                    return response.arrayBuffer()           
-                } else {
-                    throw new Error(response.statusText)
+        getAssetSize(path, callback) {
+            fetch('./assets/' + path, { method: 'HEAD' }).then((response) => {
+                if (!response.ok) {
+                    throw new Error(response.status + ' ' + response.statusText)
                 }
-            })
-            .then((content) => {
-                if (content) {
-                    assetsMap[path] = new Uint8Array(content)                    
-                } else {
-                    throw new Error("empty content") 
+                let length = response.headers.get('Content-Length')
+                if (length !== null) {
+                    return parseInt(length)
                 }
-                callback(content.byteLength, null)
+                // No length available, fallback to full content kept for next reads
+                return fullAsset(path, fetch('./assets/' + path).then((response) => {
+                    if (!response.ok) {
+                        throw new Error(response.status + ' ' + response.statusText)
+                    }
+                    return response
+                })).then((content) => content.byteLength)
+            })
+            .then((size) => {
+                callback(size, null)
             })
             .catch((error) => {
-                callback(null, error)
+                callback(null, error.toString())
             });
         },
 
-        loadAsset(path, goData, callback) {
-            if (assetsMap[path]) {
-                goData.set(assetsMap[path])
-                delete assetsMap[path]
-                callback(null)
+        loadAssetRange(path, start, end, callback) {
+            let full = fullAssets.get(path)
+            let content = null
+            if (full !== undefined) {
+                content = full.then((content) => ({ full: content }))
             } else {
-                callback("empty content")
+                content = fetch('./assets/' + path, { headers: { 'Range': 'bytes=' + start + '-' + (end - 1) } }).then((response) => {
+                    if (!response.ok) {
+                        throw new Error(response.statusText)
+                    }
+                    if (response.status === 206) {
+                        return response.arrayBuffer().then((content) => ({ partial: content }))
+                    }
+                    // Range not supported by server, full content is kept for next ranges
+                    return fullAsset(path, Promise.resolve(response)).then((content) => ({ full: content }))
+                })
             }
+            content.then((result) => {
+                if (result.partial !== undefined) {
+                    return new Uint8Array(result.partial)
+                }
+                return new Uint8Array(result.full, start, Math.max(0, Math.min(end, result.full.byteLength) - start))
+            })
+            .then((content) => {
+                callback(content, null)
+            })
+            .catch((error) => {
+                fullAssets.delete(path)
+                callback(null, error.toString())
+            });
+        },
+
+        releaseAsset(path) {
+            fullAssets.delete(path)
+        },
+
+        userFSGet(key, callback) {
+            userCallback(userTransaction('readonly', (store, done) => {
+                let request = store.get(key)
+                request.onsuccess = () => done(request.result === undefined ? null : request.result)
+            }), callback)
+        },
+
+        userFSPut(key, data, callback) {
+            userCallback(userTransaction('readwrite', (store) => {
+                store.put({ data: data, modTime: Date.now() }, key)
+            }), callback)
+        },
+
+        userFSDelete(key, callback) {
+            userCallback(userTransaction('readwrite', (store) => {
+                store.delete(key)
+            }), callback)
+        },
+
+        userFSRename(oldKey, newKey, callback) {
+            userCallback(userTransaction('readwrite', (store, done, fail) => {
+                let request = store.get(oldKey)
+                request.onsuccess = () => {
+                    if (request.result === undefined) {
+                        fail(new Error('file does not exist'))
+                    } else {
+                        store.put(request.result, newKey)
+                        store.delete(oldKey)
+                    }
+                }
+            }), callback)
+        },
+
+        userFSList(prefix, callback) {
+            userCallback(userTransaction('readonly', (store, done) => {
+                let files = []
+                let request = store.openCursor(IDBKeyRange.bound(prefix, prefix + '\uffff'))
+                request.onsuccess = () => {
+                    let cursor = request.result
+                    if (cursor) {
+                        files.push({ key: cursor.key, size: cursor.value.data.byteLength, modTime: cursor.value.modTime })
+                        cursor.continue()
+                    } else {
+                        done(files)
+                    }
+                }
+            }), callback)
         },
 
         createAudioBuffer(audioCtx, path, callback) {
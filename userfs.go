@@ -0,0 +1,247 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+package tge
+
+import (
+	bytes "bytes"
+	errors "errors"
+	io "io"
+	fs "io/fs"
+	strings "strings"
+	sync "sync"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed Settings.UserFSQuota
+var ErrQuotaExceeded = errors.New("user data quota exceeded")
+
+// UserFS is a writable file system rooted at the user data directory of the App, it
+// allows to write save files, screenshots or logs on all targets. Paths are always
+// slash separated and relative to the root, paths escaping the root are rejected.
+//
+// UserFS implements fs.FS, fs.ReadDirFS, fs.ReadFileFS and fs.StatFS.
+type UserFS struct {
+	mutex   sync.Mutex
+	backend userFSBackend
+	err     error
+	quota   int64
+	used    int64
+	counted bool
+}
+
+// userFSBackend is the target specific storage of UserFS, names are already
+// validated and writes are expected to be atomic
+type userFSBackend interface {
+	fs.FS
+	fs.ReadDirFS
+	fs.StatFS
+	writeFile(name string, content []byte) error
+	remove(name string) error
+	mkdirAll(name string) error
+	rename(oldname, newname string) error
+	usage() (int64, error)
+}
+
+// newUserFS creates an UserFS on backend, err is returned by all operations if
+// the backend could not be created
+func newUserFS(backend userFSBackend, err error, quota int64) *UserFS {
+	if err != nil {
+		_logger.Error("Failed to create user file system", "error", err)
+	}
+	return &UserFS{backend: backend, err: err, quota: quota}
+}
+
+// setQuota changes the quota once Settings are final
+func (u *UserFS) setQuota(quota int64) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.quota = quota
+}
+
+func (core *runtimeCore) GetUserFS() *UserFS {
+	return core.userFS
+}
+
+// Open implements fs.FS
+func (u *UserFS) Open(name string) (fs.File, error) {
+	if err := u.check("open", name, true); err != nil {
+		return nil, err
+	}
+	return u.backend.Open(name)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (u *UserFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := u.check("readdir", name, true); err != nil {
+		return nil, err
+	}
+	return u.backend.ReadDir(name)
+}
+
+// Stat implements fs.StatFS
+func (u *UserFS) Stat(name string) (fs.FileInfo, error) {
+	if err := u.check("stat", name, true); err != nil {
+		return nil, err
+	}
+	return u.backend.Stat(name)
+}
+
+// ReadFile implements fs.ReadFileFS
+func (u *UserFS) ReadFile(name string) ([]byte, error) {
+	if err := u.check("read", name, false); err != nil {
+		return nil, err
+	}
+	file, err := u.backend.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// WriteFile replaces the content of the named file atomically, parent directories
+// are created if needed
+func (u *UserFS) WriteFile(name string, content []byte) error {
+	if err := u.check("write", name, false); err != nil {
+		return err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	delta := int64(len(content)) - u.size(name)
+	if err := u.reserve(delta); err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	if err := u.backend.writeFile(name, content); err != nil {
+		return err
+	}
+	u.used += delta
+	return nil
+}
+
+// Create returns a writer on the named file, the content is written atomically
+// when the writer is closed
+func (u *UserFS) Create(name string) (io.WriteCloser, error) {
+	if err := u.check("create", name, false); err != nil {
+		return nil, err
+	}
+	return &userFile{userFS: u, name: name}, nil
+}
+
+// Remove removes the named file or empty directory
+func (u *UserFS) Remove(name string) error {
+	if err := u.check("remove", name, false); err != nil {
+		return err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	size := u.size(name)
+	if err := u.backend.remove(name); err != nil {
+		return err
+	}
+	u.used -= size
+	return nil
+}
+
+// MkdirAll creates the named directory and its parents
+func (u *UserFS) MkdirAll(name string) error {
+	if err := u.check("mkdir", name, false); err != nil {
+		return err
+	}
+	return u.backend.mkdirAll(name)
+}
+
+// Rename moves oldname to newname, newname is replaced if it exists
+func (u *UserFS) Rename(oldname, newname string) error {
+	if err := u.check("rename", oldname, false); err != nil {
+		return err
+	}
+	if err := u.check("rename", newname, false); err != nil {
+		return err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	replaced := u.size(newname)
+	if err := u.backend.rename(oldname, newname); err != nil {
+		return err
+	}
+	u.used -= replaced
+	return nil
+}
+
+// Usage returns the bytes used by the files and the quota set in Settings.UserFSQuota,
+// a quota of 0 means unlimited
+func (u *UserFS) Usage() (used int64, quota int64, err error) {
+	if u.err != nil {
+		return 0, 0, u.err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if err := u.count(); err != nil {
+		return 0, u.quota, err
+	}
+	return u.used, u.quota, nil
+}
+
+// check validates name and the state of the file system, the root "." is only
+// allowed for reading
+func (u *UserFS) check(op string, name string, allowRoot bool) error {
+	if u.err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: u.err}
+	}
+	// Backslashes and drive letters would escape the root on windows
+	if !fs.ValidPath(name) || strings.ContainsAny(name, `\:`) || (name == "." && !allowRoot) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// size returns the size of the named file, 0 if not found or directory
+func (u *UserFS) size(name string) int64 {
+	if info, err := u.backend.Stat(name); err == nil && !info.IsDir() {
+		return info.Size()
+	}
+	return 0
+}
+
+// reserve checks that delta bytes can be added without exceeding the quota
+func (u *UserFS) reserve(delta int64) error {
+	if u.quota <= 0 || delta <= 0 {
+		return nil
+	}
+	if err := u.count(); err != nil {
+		return err
+	}
+	if u.used+delta > u.quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// count computes the used bytes once, it is then updated by each operation
+func (u *UserFS) count() error {
+	if u.counted {
+		return nil
+	}
+	used, err := u.backend.usage()
+	if err != nil {
+		return err
+	}
+	u.used = used
+	u.counted = true
+	return nil
+}
+
+// userFile buffers the content written by Create() until Close()
+type userFile struct {
+	bytes.Buffer
+	userFS *UserFS
+	name   string
+	closed bool
+}
+
+func (f *userFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return f.userFS.WriteFile(f.name, f.Bytes())
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build js
+// +build js
+
+package tge
+
+import (
+	bytes "bytes"
+	errors "errors"
+	io "io"
+	fs "io/fs"
+	path "path"
+	sort "sort"
+	strings "strings"
+	js "syscall/js"
+	time "time"
+)
+
+// browserUserFSBackend stores user files in IndexedDB, each file is a record whose
+// key is its path prefixed by the App name. Directories are implicit: they exist as
+// long as they contain files and MkdirAll() has no effect.
+type browserUserFSBackend struct {
+	jsTge  *js.Value
+	prefix string
+}
+
+// newUserFSBackend stores user files in the IndexedDB of the origin
+func newUserFSBackend(settings Settings) (userFSBackend, error) {
+	jsTge := js.Global().Get("tge")
+	if js.Global().Get("indexedDB").IsUndefined() {
+		return nil, ErrNotSupported
+	}
+	return &browserUserFSBackend{jsTge: &jsTge, prefix: appDirName(settings.Name) + "/"}, nil
+}
+
+func (backend *browserUserFSBackend) Open(name string) (fs.File, error) {
+	value, err := jsCallAsync(backend.jsTge, "userFSGet", backend.prefix+name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if value.IsNull() {
+		entries, err := backend.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &browserUserDir{info: browserUserFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+	data := value.Get("data")
+	content := make([]byte, data.Get("length").Int())
+	js.CopyBytesToGo(content, data)
+	return &browserUserFile{
+		Reader: bytes.NewReader(content),
+		info: browserUserFileInfo{
+			name:    path.Base(name),
+			size:    int64(len(content)),
+			modTime: time.Unix(0, int64(value.Get("modTime").Float())*int64(time.Millisecond)),
+		},
+	}, nil
+}
+
+func (backend *browserUserFSBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := backend.prefix
+	if name != "." {
+		prefix += name + "/"
+	}
+	files, err := backend.list(prefix)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if name != "." && len(files) == 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	dirs := make(map[string]bool)
+	entries := []fs.DirEntry{}
+	for _, info := range files {
+		relative := strings.TrimPrefix(info.name, prefix)
+		if i := strings.Index(relative, "/"); i >= 0 {
+			if !dirs[relative[:i]] {
+				dirs[relative[:i]] = true
+				entries = append(entries, fs.FileInfoToDirEntry(browserUserFileInfo{name: relative[:i], dir: true}))
+			}
+		} else {
+			info.name = relative
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (backend *browserUserFSBackend) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return browserUserFileInfo{name: ".", dir: true}, nil
+	}
+	files, err := backend.list(backend.prefix + name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	for _, info := range files {
+		relative := strings.TrimPrefix(info.name, backend.prefix)
+		if relative == name {
+			info.name = path.Base(name)
+			return info, nil
+		} else if strings.HasPrefix(relative, name+"/") {
+			return browserUserFileInfo{name: path.Base(name), dir: true}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (backend *browserUserFSBackend) writeFile(name string, content []byte) error {
+	if info, err := backend.Stat(name); err == nil && info.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("is a directory")}
+	}
+	data := js.Global().Get("Uint8Array").New(len(content))
+	js.CopyBytesToJS(data, content)
+	if _, err := jsCallAsync(backend.jsTge, "userFSPut", backend.prefix+name, data); err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	return nil
+}
+
+func (backend *browserUserFSBackend) remove(name string) error {
+	info, err := backend.Stat(name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	if _, err := jsCallAsync(backend.jsTge, "userFSDelete", backend.prefix+name); err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return nil
+}
+
+func (backend *browserUserFSBackend) mkdirAll(name string) error {
+	return nil
+}
+
+func (backend *browserUserFSBackend) rename(oldname, newname string) error {
+	info, err := backend.Stat(oldname)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: ErrNotSupported}
+	}
+	if _, err := jsCallAsync(backend.jsTge, "userFSRename", backend.prefix+oldname, backend.prefix+newname); err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	return nil
+}
+
+func (backend *browserUserFSBackend) usage() (int64, error) {
+	files, err := backend.list(backend.prefix)
+	if err != nil {
+		return 0, err
+	}
+	used := int64(0)
+	for _, info := range files {
+		used += info.size
+	}
+	return used, nil
+}
+
+// list returns the files whose key starts with prefix, names are the full keys
+func (backend *browserUserFSBackend) list(prefix string) ([]browserUserFileInfo, error) {
+	value, err := jsCallAsync(backend.jsTge, "userFSList", prefix)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]browserUserFileInfo, value.Length())
+	for i := range files {
+		file := value.Index(i)
+		files[i] = browserUserFileInfo{
+			name:    file.Get("key").String(),
+			size:    int64(file.Get("size").Int()),
+			modTime: time.Unix(0, int64(file.Get("modTime").Float())*int64(time.Millisecond)),
+		}
+	}
+	return files, nil
+}
+
+// browserUserFile is an opened user file, its content is fully loaded
+type browserUserFile struct {
+	*bytes.Reader
+	info browserUserFileInfo
+}
+
+func (f *browserUserFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *browserUserFile) Close() error {
+	return nil
+}
+
+// browserUserDir is an opened implicit directory
+type browserUserDir struct {
+	info    browserUserFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *browserUserDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *browserUserDir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *browserUserDir) Close() error {
+	return nil
+}
+
+func (d *browserUserDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := len(d.entries) - d.offset
+	if count > 0 && remaining == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < remaining {
+		remaining = count
+	}
+	list := d.entries[d.offset : d.offset+remaining]
+	d.offset += remaining
+	return list, nil
+}
+
+// browserUserFileInfo is the fs.FileInfo of user files and implicit directories
+type browserUserFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i browserUserFileInfo) Name() string {
+	return i.name
+}
+
+func (i browserUserFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i browserUserFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (i browserUserFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i browserUserFileInfo) IsDir() bool {
+	return i.dir
+}
+
+func (i browserUserFileInfo) Sys() interface{} {
+	return nil
+}
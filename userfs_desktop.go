@@ -0,0 +1,41 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build (darwin || freebsd || linux || windows) && !android && !ios && !js
+// +build darwin freebsd linux windows
+// +build !android
+// +build !ios
+// +build !js
+
+package tge
+
+import (
+	os "os"
+	filepath "path/filepath"
+	runtime "runtime"
+)
+
+// newUserFSBackend roots UserFS in the App folder of the user data directory
+func newUserFSBackend(settings Settings) (userFSBackend, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return newDirUserFSBackend(filepath.Join(dir, appDirName(settings.Name))), nil
+}
+
+// userDataDir returns XDG_DATA_HOME on unix systems, AppData on windows and
+// Application Support on macOS
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
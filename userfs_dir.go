@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !js
+// +build !js
+
+package tge
+
+import (
+	fs "io/fs"
+	os "os"
+	filepath "path/filepath"
+)
+
+// Name of the UserFS root folder in the App data directory
+const userFSDirName = "userdata"
+
+// dirUserFSBackend stores user files in a directory of the host file system
+type dirUserFSBackend struct {
+	fs.FS
+	root string
+}
+
+// newDirUserFSBackend creates a backend rooted at the userdata folder of dir
+func newDirUserFSBackend(dir string) *dirUserFSBackend {
+	root := filepath.Join(dir, userFSDirName)
+	return &dirUserFSBackend{FS: os.DirFS(root), root: root}
+}
+
+func (backend *dirUserFSBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "." {
+		// The root is created on first write
+		if _, err := os.Stat(backend.root); os.IsNotExist(err) {
+			return []fs.DirEntry{}, nil
+		}
+	}
+	return fs.ReadDir(backend.FS, name)
+}
+
+func (backend *dirUserFSBackend) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(backend.FS, name)
+}
+
+func (backend *dirUserFSBackend) writeFile(name string, content []byte) error {
+	return writeFileAtomic(backend.path(name), content)
+}
+
+func (backend *dirUserFSBackend) remove(name string) error {
+	return os.Remove(backend.path(name))
+}
+
+func (backend *dirUserFSBackend) mkdirAll(name string) error {
+	return os.MkdirAll(backend.path(name), 0700)
+}
+
+func (backend *dirUserFSBackend) rename(oldname, newname string) error {
+	if err := os.MkdirAll(filepath.Dir(backend.path(newname)), 0700); err != nil {
+		return err
+	}
+	return os.Rename(backend.path(oldname), backend.path(newname))
+}
+
+func (backend *dirUserFSBackend) usage() (int64, error) {
+	used := int64(0)
+	err := filepath.WalkDir(backend.root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == backend.root {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			used += info.Size()
+		}
+		return nil
+	})
+	return used, err
+}
+
+// path converts a validated name to a host path
+func (backend *dirUserFSBackend) path(name string) string {
+	return filepath.Join(backend.root, filepath.FromSlash(name))
+}
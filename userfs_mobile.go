@@ -0,0 +1,15 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build android || ios
+// +build android ios
+
+package tge
+
+// newUserFSBackend roots UserFS in the files directory of the App
+func newUserFSBackend(settings Settings) (userFSBackend, error) {
+	dir, err := mobileFilesDir()
+	if err != nil {
+		return nil, err
+	}
+	return newDirUserFSBackend(dir), nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2019 Thomas MILLET. All rights reserved.
+
+//go:build !js
+// +build !js
+
+package tge
+
+import (
+	bytes "bytes"
+	errors "errors"
+	io "io"
+	fs "io/fs"
+	strconv "strconv"
+	strings "strings"
+	testing "testing"
+	fstest "testing/fstest"
+)
+
+func TestUserFSPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		readable  bool
+		writeable bool
+	}{
+		{"save.json", true, true},
+		{"saves/slot1.json", true, true},
+		{".", true, false},
+		{"", false, false},
+		{"/etc/passwd", false, false},
+		{"../outside", false, false},
+		{"saves/../../outside", false, false},
+		{"saves/./slot1.json", false, false},
+		{"saves/", false, false},
+		{`saves\slot1.json`, false, false},
+		{"C:/outside", false, false},
+	}
+	userFS := newUserFS(newDirUserFSBackend(t.TempDir()), nil, 0)
+	for _, test := range tests {
+		if _, err := userFS.Stat(test.name); errors.Is(err, fs.ErrInvalid) == test.readable {
+			t.Errorf("Stat(%q) = %v, want readable %v", test.name, err, test.readable)
+		}
+		err := userFS.WriteFile(test.name, []byte("content"))
+		if errors.Is(err, fs.ErrInvalid) == test.writeable || (test.writeable && err != nil) {
+			t.Errorf("WriteFile(%q) = %v, want writeable %v", test.name, err, test.writeable)
+		}
+	}
+}
+
+func TestUserFSQuota(t *testing.T) {
+	tests := []struct {
+		name  string
+		quota int64
+		ops   string
+		used  int64
+		err   error
+	}{
+		{"unlimited", 0, "write a 100, write b 100", 200, nil},
+		{"within quota", 10, "write a 4, write b 6", 10, nil},
+		{"exceeded", 10, "write a 4, write b 7", 4, ErrQuotaExceeded},
+		{"replaced file", 10, "write a 8, write a 10", 10, nil},
+		{"shrinking over quota", 10, "write a 8, write a 2", 2, nil},
+		{"removed file", 10, "write a 8, remove a, write b 10", 10, nil},
+		{"renamed over file", 10, "write a 4, write b 6, rename a b, write c 6", 10, nil},
+		{"created file", 10, "create a 6, create b 6", 6, ErrQuotaExceeded},
+		{"nested files", 10, "write d/a 5, write d/e/b 5, write c 1", 10, ErrQuotaExceeded},
+	}
+	for _, test := range tests {
+		userFS := newUserFS(newDirUserFSBackend(t.TempDir()), nil, test.quota)
+		var err error
+		for _, op := range strings.Split(test.ops, ", ") {
+			fields := strings.Fields(op)
+			switch fields[0] {
+			case "write":
+				size, _ := strconv.Atoi(fields[2])
+				err = userFS.WriteFile(fields[1], bytes.Repeat([]byte("x"), size))
+			case "create":
+				size, _ := strconv.Atoi(fields[2])
+				var file io.WriteCloser
+				if file, err = userFS.Create(fields[1]); err == nil {
+					file.Write(bytes.Repeat([]byte("x"), size))
+					err = file.Close()
+				}
+			case "remove":
+				err = userFS.Remove(fields[1])
+			case "rename":
+				err = userFS.Rename(fields[1], fields[2])
+			}
+			if err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+		if used, quota, err := userFS.Usage(); err != nil || used != test.used || quota != test.quota {
+			t.Errorf("%s: Usage() = %d, %d, %v, want %d, %d", test.name, used, quota, err, test.used, test.quota)
+		}
+	}
+}
+
+func TestUserFSCountsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := newUserFS(newDirUserFSBackend(dir), nil, 0).WriteFile("old/save", make([]byte, 8)); err != nil {
+		t.Fatal(err)
+	}
+	userFS := newUserFS(newDirUserFSBackend(dir), nil, 10)
+	if err := userFS.WriteFile("new", make([]byte, 4)); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("WriteFile() = %v, want ErrQuotaExceeded", err)
+	}
+	if err := fstest.TestFS(userFS, "old/save"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUserFSUnavailable(t *testing.T) {
+	errUnavailable := errors.New("no home")
+	userFS := newUserFS(nil, errUnavailable, 0)
+	if _, err := userFS.ReadFile("save"); !errors.Is(err, errUnavailable) {
+		t.Errorf("ReadFile() = %v, want %v", err, errUnavailable)
+	}
+	if err := userFS.WriteFile("save", nil); !errors.Is(err, errUnavailable) {
+		t.Errorf("WriteFile() = %v, want %v", err, errUnavailable)
+	}
+	if _, _, err := userFS.Usage(); !errors.Is(err, errUnavailable) {
+		t.Errorf("Usage() = %v, want %v", err, errUnavailable)
+	}
+}